@@ -0,0 +1,63 @@
+package cache
+
+import "errors"
+
+// BatchCache is implemented by caches that serve several keys in one call, paying for locking and eviction once
+type BatchCache[K comparable, V any] interface {
+	Cache[K, V]
+	// GetMany returns the values of the keys found in the cache and, in request order, the keys that were not
+	GetMany(keys []K) (found map[K]V, missing []K)
+	SetMany(entries map[K]V) error
+	// DeleteMany removes every given key that is present and reports how many were removed
+	DeleteMany(keys []K) int
+}
+
+// GetMany uses c's native GetMany when it is a BatchCache and falls back to one Get per key otherwise.
+// In the fallback any Get error counts the key as missing.
+func GetMany[K comparable, V any](c Cache[K, V], keys []K) (map[K]V, []K) {
+	if batch, ok := c.(BatchCache[K, V]); ok {
+		return batch.GetMany(keys)
+	}
+
+	found := make(map[K]V, len(keys))
+	var missing []K
+	for _, key := range keys {
+		if value, err := c.Get(key); err == nil {
+			found[key] = value
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	return found, missing
+}
+
+// SetMany uses c's native SetMany when it is a BatchCache and falls back to one Set per entry otherwise,
+// joining the errors of the entries that failed
+func SetMany[K comparable, V any](c Cache[K, V], entries map[K]V) error {
+	if batch, ok := c.(BatchCache[K, V]); ok {
+		return batch.SetMany(entries)
+	}
+
+	var errs []error
+	for key, value := range entries {
+		if err := c.Set(key, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeleteMany uses c's native DeleteMany when it is a BatchCache and falls back to one Delete per key otherwise
+func DeleteMany[K comparable, V any](c Cache[K, V], keys []K) int {
+	if batch, ok := c.(BatchCache[K, V]); ok {
+		return batch.DeleteMany(keys)
+	}
+
+	deleted := 0
+	for _, key := range keys {
+		if c.Delete(key) == nil {
+			deleted++
+		}
+	}
+	return deleted
+}
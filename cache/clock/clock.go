@@ -0,0 +1,55 @@
+// Package clock abstracts the passage of time, so time-dependent caches can be driven by a fake clock in tests
+package clock
+
+import "time"
+
+// Clock is the source of time of a cache: its current time, and the tickers and timers its background work runs on
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+	// AfterFunc calls f once d has passed, like time.AfterFunc
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Ticker delivers ticks on C like a time.Ticker
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Timer cancels a call scheduled by AfterFunc like a time.Timer
+type Timer interface {
+	// Stop prevents the call, reporting whether it had not happened yet
+	Stop() bool
+}
+
+// Real returns the wall clock of package time
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{ticker: time.NewTicker(d)}
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
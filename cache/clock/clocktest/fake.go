@@ -0,0 +1,142 @@
+// Package clocktest provides a manual clock for testing time-dependent caches without sleeping
+package clocktest
+
+import (
+	"github.com/kimvlry/caching/cache/clock"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeClock is a clock.Clock that only moves when told to.
+// Advance fires the timers and ticks the tickers that came due, in the order of their deadlines,
+// with Now reporting each deadline while it is handled. Functions scheduled by AfterFunc run on the goroutine calling
+// Advance, so their effects are visible as soon as it returns; ticks are delivered like those of a time.Ticker,
+// and are dropped when the previous one was not received yet.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+}
+
+// waiter is a pending timer or ticker
+type waiter struct {
+	clock  *FakeClock
+	at     time.Time
+	period time.Duration // zero for a timer
+	fn     func()
+	c      chan time.Time
+}
+
+// NewFakeClock returns a clock standing at now
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (f *FakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// NewTicker panics on a non-positive period, like time.NewTicker
+func (f *FakeClock) NewTicker(d time.Duration) clock.Ticker {
+	if d <= 0 {
+		panic("clocktest: non-positive interval for NewTicker")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	w := &waiter{clock: f, at: f.now.Add(d), period: d, c: make(chan time.Time, 1)}
+	f.waiters = append(f.waiters, w)
+	return fakeTicker{w}
+}
+
+func (f *FakeClock) AfterFunc(d time.Duration, fn func()) clock.Timer {
+	f.mu.Lock()
+	w := &waiter{clock: f, at: f.now.Add(d), fn: fn}
+	f.waiters = append(f.waiters, w)
+	f.mu.Unlock()
+
+	if d <= 0 {
+		f.Advance(0)
+	}
+	return fakeTimer{w}
+}
+
+// Advance moves the clock forward by d, firing everything that comes due on the way
+func (f *FakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	end := f.now.Add(d)
+	for {
+		w := f.next(end)
+		if w == nil {
+			break
+		}
+		f.now = w.at
+		if w.period > 0 {
+			select {
+			case w.c <- w.at:
+			default:
+			}
+			w.at = w.at.Add(w.period)
+			continue
+		}
+		f.remove(w)
+		f.mu.Unlock()
+		w.fn()
+		f.mu.Lock()
+	}
+	if end.After(f.now) {
+		f.now = end
+	}
+	f.mu.Unlock()
+}
+
+// next returns the waiter due first, if it is due by end
+func (f *FakeClock) next(end time.Time) *waiter {
+	sort.SliceStable(f.waiters, func(i, j int) bool {
+		return f.waiters[i].at.Before(f.waiters[j].at)
+	})
+	if len(f.waiters) == 0 || f.waiters[0].at.After(end) {
+		return nil
+	}
+	return f.waiters[0]
+}
+
+func (f *FakeClock) remove(w *waiter) bool {
+	for i, other := range f.waiters {
+		if other == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// stop cancels the waiter, reporting whether it was still pending
+func (w *waiter) stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	return w.clock.remove(w)
+}
+
+type fakeTicker struct {
+	w *waiter
+}
+
+func (t fakeTicker) C() <-chan time.Time {
+	return t.w.c
+}
+
+func (t fakeTicker) Stop() {
+	t.w.stop()
+}
+
+type fakeTimer struct {
+	w *waiter
+}
+
+func (t fakeTimer) Stop() bool {
+	return t.w.stop()
+}
@@ -0,0 +1,74 @@
+package clocktest_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kimvlry/caching/cache/clock/clocktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFakeClockAfterFunc tests that Advance runs due functions in deadline order, with Now at their deadline
+func TestFakeClockAfterFunc(t *testing.T) {
+	start := time.Unix(0, 0)
+	clk := clocktest.NewFakeClock(start)
+
+	var fired []time.Duration
+	record := func() { fired = append(fired, clk.Now().Sub(start)) }
+	clk.AfterFunc(3*time.Second, record)
+	clk.AfterFunc(time.Second, record)
+	stopped := clk.AfterFunc(2*time.Second, record)
+	require.True(t, stopped.Stop())
+
+	clk.Advance(time.Second)
+	assert.Equal(t, []time.Duration{time.Second}, fired)
+
+	clk.Advance(5 * time.Second)
+	assert.Equal(t, []time.Duration{time.Second, 3 * time.Second}, fired)
+	assert.Equal(t, start.Add(6*time.Second), clk.Now())
+	assert.False(t, stopped.Stop())
+}
+
+// TestFakeClockAfterFuncReschedules tests that a function may schedule another one that comes due in the same Advance
+func TestFakeClockAfterFuncReschedules(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Unix(0, 0))
+
+	runs := 0
+	var tick func()
+	tick = func() {
+		runs++
+		clk.AfterFunc(time.Second, tick)
+	}
+	clk.AfterFunc(time.Second, tick)
+
+	clk.Advance(3500 * time.Millisecond)
+	assert.Equal(t, 3, runs)
+}
+
+// TestFakeClockTicker tests that a ticker ticks once per period and drops ticks nobody received, like time.Ticker
+func TestFakeClockTicker(t *testing.T) {
+	start := time.Unix(0, 0)
+	clk := clocktest.NewFakeClock(start)
+	ticker := clk.NewTicker(time.Second)
+
+	select {
+	case <-ticker.C():
+		t.Fatal("ticker should not tick before its period")
+	default:
+	}
+
+	clk.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), <-ticker.C())
+
+	clk.Advance(3 * time.Second)
+	assert.Equal(t, start.Add(2*time.Second), <-ticker.C(), "later ticks should be dropped while one is pending")
+
+	ticker.Stop()
+	clk.Advance(time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatal("stopped ticker should not tick")
+	default:
+	}
+}
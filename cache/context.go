@@ -0,0 +1,84 @@
+package cache
+
+import "context"
+
+// ContextCache mirrors Cache with methods that carry a context, so deadlines, cancellation and tracing
+// can flow through decorators down to the cache doing the work
+type ContextCache[K comparable, V any] interface {
+	GetCtx(ctx context.Context, key K) (V, error)
+	SetCtx(ctx context.Context, key K, value V) error
+	DeleteCtx(ctx context.Context, key K) error
+	Clear()
+}
+
+// ToContextCache adapts c to ContextCache. Caches that already implement it are returned unchanged,
+// others fail fast with ctx.Err() once ctx is done and otherwise ignore the context.
+func ToContextCache[K comparable, V any](c Cache[K, V]) ContextCache[K, V] {
+	if cc, ok := any(c).(ContextCache[K, V]); ok {
+		return cc
+	}
+	return &contextAdapter[K, V]{c}
+}
+
+// FromContextCache adapts cc to Cache, running every operation with context.Background().
+// Context caches that already implement Cache are returned unchanged.
+func FromContextCache[K comparable, V any](cc ContextCache[K, V]) Cache[K, V] {
+	if c, ok := any(cc).(Cache[K, V]); ok {
+		return c
+	}
+	return &plainAdapter[K, V]{cc}
+}
+
+type contextAdapter[K comparable, V any] struct {
+	Cache[K, V]
+}
+
+func (a *contextAdapter[K, V]) GetCtx(ctx context.Context, key K) (V, error) {
+	if err := ctx.Err(); err != nil {
+		var zero V
+		return zero, err
+	}
+	return a.Get(key)
+}
+
+func (a *contextAdapter[K, V]) SetCtx(ctx context.Context, key K, value V) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.Set(key, value)
+}
+
+func (a *contextAdapter[K, V]) DeleteCtx(ctx context.Context, key K) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return a.Delete(key)
+}
+
+func (a *contextAdapter[K, V]) Range(fn func(K, V) bool) {
+	if iterable, ok := a.Cache.(IterableCache[K, V]); ok {
+		iterable.Range(fn)
+	}
+}
+
+type plainAdapter[K comparable, V any] struct {
+	ContextCache[K, V]
+}
+
+func (a *plainAdapter[K, V]) Get(key K) (V, error) {
+	return a.GetCtx(context.Background(), key)
+}
+
+func (a *plainAdapter[K, V]) Set(key K, value V) error {
+	return a.SetCtx(context.Background(), key, value)
+}
+
+func (a *plainAdapter[K, V]) Delete(key K) error {
+	return a.DeleteCtx(context.Background(), key)
+}
+
+func (a *plainAdapter[K, V]) Range(fn func(K, V) bool) {
+	if iterable, ok := a.ContextCache.(interface{ Range(func(K, V) bool) }); ok {
+		iterable.Range(fn)
+	}
+}
@@ -0,0 +1,52 @@
+package decorators
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecorators_PassBatchesThrough(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	decorated := map[string]cache.Cache[string, int]{
+		"metrics":     WithMetrics(strategies.NewLruCache[string, int](10)()),
+		"logging":     WithDebugLogging(strategies.NewLruCache[string, int](10)(), logger),
+		"bloom":       WithBloomFilter(strategies.NewLruCache[string, int](10)(), 10, 0.01),
+		"compression": WithCompression[string, int](strategies.NewLruCache[string, []byte](10)(), nil),
+	}
+
+	for name, c := range decorated {
+		t.Run(name, func(t *testing.T) {
+			batch, ok := c.(cache.BatchCache[string, int])
+			require.True(t, ok, "decorator should implement BatchCache")
+
+			require.NoError(t, batch.SetMany(map[string]int{"a": 1, "b": 2}))
+
+			found, missing := batch.GetMany([]string{"a", "b", "c"})
+			assert.Equal(t, map[string]int{"a": 1, "b": 2}, found)
+			assert.Equal(t, []string{"c"}, missing)
+
+			assert.Equal(t, 1, batch.DeleteMany([]string{"a", "c"}))
+			_, err := c.Get("a")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMetricsDecorator_GetManyCountsHitsAndMisses(t *testing.T) {
+	metricsCache := WithMetrics(strategies.NewLruCache[string, int](10)())
+	batch := metricsCache.(cache.BatchCache[string, int])
+
+	_ = batch.SetMany(map[string]int{"a": 1, "b": 2})
+	_, _ = batch.GetMany([]string{"a", "b", "c"})
+
+	assert.Equal(t, int64(2), metricsCache.GetHits())
+	assert.Equal(t, int64(1), metricsCache.GetMisses())
+}
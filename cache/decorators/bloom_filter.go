@@ -1,8 +1,11 @@
 package decorators
 
 import (
+	"context"
 	"fmt"
 	"hash/fnv"
+	"sync"
+	"sync/atomic"
 
 	"github.com/bits-and-blooms/bloom/v3"
 	"github.com/kimvlry/caching/cache"
@@ -11,8 +14,11 @@ import (
 
 type bloomDecorator[K comparable, V any] struct {
 	cacheWrappee cache.IterableCache[K, V]
+	ctxWrappee   cache.ContextCache[K, V]
 	filter       *bloom.BloomFilter
 	hasher       func(K) []byte
+	mu           sync.Mutex
+	stale        atomic.Bool
 }
 
 // WithBloomFilter creates a Bloom filter decorator for the given cache.
@@ -48,6 +54,7 @@ func WithBloomFilter[K comparable, V any](
 
 	decorator := &bloomDecorator[K, V]{
 		cacheWrappee: wrappee,
+		ctxWrappee:   cache.ToContextCache[K, V](wrappee),
 		filter:       filter,
 		hasher:       hasher,
 	}
@@ -56,12 +63,13 @@ func WithBloomFilter[K comparable, V any](
 	// Without this, evicted keys remain in the Bloom filter, causing false positives
 	// that make the filter progressively less useful over time
 
-	// TODO: rebuild effectively (not after every eviction??)
+	// The rebuild is deferred until the next lookup: eviction callbacks run inside the wrapped cache's Set,
+	// where Range cannot be called on caches built with strategies.WithConcurrency
 	if observable, ok := any(wrappee).(cache.ObservableCache[K, V]); ok {
 		observable.OnEvent(func(event cache.Event[K, V]) {
 			switch event.Type {
 			case cache.EventTypeEviction:
-				decorator.rebuildFilter()
+				decorator.stale.Store(true)
 			}
 		})
 	}
@@ -70,7 +78,11 @@ func WithBloomFilter[K comparable, V any](
 }
 
 // rebuildFilter reconstructs the Bloom filter from the current cache contents.
+// Must be called with b.mu held.
 func (b *bloomDecorator[K, V]) rebuildFilter() {
+	if !b.stale.Swap(false) {
+		return
+	}
 	b.filter.ClearAll()
 	b.cacheWrappee.Range(func(key K, _ V) bool {
 		b.filter.Add(b.hasher(key))
@@ -79,28 +91,90 @@ func (b *bloomDecorator[K, V]) rebuildFilter() {
 }
 
 func (b *bloomDecorator[K, V]) Get(key K) (V, error) {
-	if !b.filter.Test(b.hasher(key)) {
+	return b.GetCtx(context.Background(), key)
+}
+
+// GetCtx answers definite misses without reaching the wrapped cache, even once ctx is done
+func (b *bloomDecorator[K, V]) GetCtx(ctx context.Context, key K) (V, error) {
+	b.mu.Lock()
+	b.rebuildFilter()
+	present := b.filter.Test(b.hasher(key))
+	b.mu.Unlock()
+
+	if !present {
 		var zero V
 		return zero, common.ErrKeyNotFound
 	}
-	return b.cacheWrappee.Get(key)
+	return b.ctxWrappee.GetCtx(ctx, key)
 }
 
 func (b *bloomDecorator[K, V]) Set(key K, value V) error {
+	return b.SetCtx(context.Background(), key, value)
+}
+
+func (b *bloomDecorator[K, V]) SetCtx(ctx context.Context, key K, value V) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.filter.Add(b.hasher(key))
-	return b.cacheWrappee.Set(key, value)
+	return b.ctxWrappee.SetCtx(ctx, key, value)
 }
 
 // Delete removes from cache but not from Bloom filter.
 // The key will remain in the filter (causing potential false positives)
-// until the next eviction triggers a rebuild via rebuildFilter().
+// until the next eviction marks the filter for a rebuild via rebuildFilter().
 func (b *bloomDecorator[K, V]) Delete(key K) error {
 	return b.cacheWrappee.Delete(key)
 }
 
+func (b *bloomDecorator[K, V]) DeleteCtx(ctx context.Context, key K) error {
+	return b.ctxWrappee.DeleteCtx(ctx, key)
+}
+
+// GetMany only asks the wrapped cache for the keys the filter may contain
+func (b *bloomDecorator[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	b.mu.Lock()
+	b.rebuildFilter()
+	candidates := make([]K, 0, len(keys))
+	for _, key := range keys {
+		if b.filter.Test(b.hasher(key)) {
+			candidates = append(candidates, key)
+		}
+	}
+	b.mu.Unlock()
+
+	found, _ := cache.GetMany[K, V](b.cacheWrappee, candidates)
+	var missing []K
+	for _, key := range keys {
+		if _, ok := found[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return found, missing
+}
+
+func (b *bloomDecorator[K, V]) SetMany(entries map[K]V) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key := range entries {
+		b.filter.Add(b.hasher(key))
+	}
+	return cache.SetMany[K, V](b.cacheWrappee, entries)
+}
+
+func (b *bloomDecorator[K, V]) DeleteMany(keys []K) int {
+	return cache.DeleteMany[K, V](b.cacheWrappee, keys)
+}
+
 func (b *bloomDecorator[K, V]) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.cacheWrappee.Clear()
 	b.filter.ClearAll()
+	b.stale.Store(false)
 }
 
 func (b *bloomDecorator[K, V]) Range(fn func(K, V) bool) {
@@ -3,6 +3,7 @@ package decorators
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"github.com/kimvlry/caching/cache"
 	"io"
@@ -15,8 +16,9 @@ type Serializer[V any] interface {
 
 type compressionDecorator[K comparable, V any] struct {
 	cacheWrappee   cache.Cache[K, []byte]
+	ctxWrappee     cache.ContextCache[K, []byte]
 	serializerWrap Serializer[V]
-	eventCallbacks []func(cache.Event[K, V])
+	events         *cache.EventDispatcher[K, V]
 }
 
 func WithCompression[K comparable, V any](
@@ -26,17 +28,32 @@ func WithCompression[K comparable, V any](
 
 	return &compressionDecorator[K, V]{
 		cacheWrappee:   wrappee,
+		ctxWrappee:     cache.ToContextCache(wrappee),
 		serializerWrap: serializer,
+		events:         cache.NewEventDispatcher[K, V](),
 	}
 }
 
 func (w *compressionDecorator[K, V]) Get(key K) (V, error) {
-	compressed, err := w.cacheWrappee.Get(key)
+	return w.GetCtx(context.Background(), key)
+}
+
+// GetCtx gives up before decompressing once ctx is done
+func (w *compressionDecorator[K, V]) GetCtx(ctx context.Context, key K) (V, error) {
+	compressed, err := w.ctxWrappee.GetCtx(ctx, key)
 	if err != nil {
 		var zero V
 		return zero, err
 	}
+	if err := ctx.Err(); err != nil {
+		var zero V
+		return zero, err
+	}
+
+	return decode[V](compressed)
+}
 
+func decode[V any](compressed []byte) (V, error) {
 	raw, err := decompressRaw(compressed)
 	if err != nil {
 		var zero V
@@ -52,33 +69,97 @@ func (w *compressionDecorator[K, V]) Get(key K) (V, error) {
 }
 
 func (w *compressionDecorator[K, V]) Set(key K, value V) error {
+	return w.SetCtx(context.Background(), key, value)
+}
+
+// SetCtx checks ctx between serialization, compression and storing, so a cancelled write does no further work
+func (w *compressionDecorator[K, V]) SetCtx(ctx context.Context, key K, value V) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	rawBytes, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
-	w.emit(cache.Event[K, V]{
+	w.events.Emit(cache.Event[K, V]{
 		Type: cache.EventTypeReadBytes,
 		Key:  key,
 		Size: len(rawBytes),
 	})
 
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	compressedBytes, err := compressRaw(rawBytes)
 	if err != nil {
 		return err
 	}
-	w.emit(cache.Event[K, V]{
+	w.events.Emit(cache.Event[K, V]{
 		Type: cache.EventTypeCompressBytes,
 		Key:  key,
 		Size: len(compressedBytes),
 	})
 
-	return w.cacheWrappee.Set(key, compressedBytes)
+	return w.ctxWrappee.SetCtx(ctx, key, compressedBytes)
 }
 
 func (w *compressionDecorator[K, V]) Delete(key K) error {
 	return w.cacheWrappee.Delete(key)
 }
 
+func (w *compressionDecorator[K, V]) DeleteCtx(ctx context.Context, key K) error {
+	return w.ctxWrappee.DeleteCtx(ctx, key)
+}
+
+// GetMany reports keys whose stored bytes cannot be decoded as missing
+func (w *compressionDecorator[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	compressed, _ := cache.GetMany(w.cacheWrappee, keys)
+	found := make(map[K]V, len(compressed))
+	var missing []K
+	for _, key := range keys {
+		if data, ok := compressed[key]; ok {
+			if v, err := decode[V](data); err == nil {
+				found[key] = v
+				continue
+			}
+		}
+		missing = append(missing, key)
+	}
+	return found, missing
+}
+
+// SetMany encodes every entry before storing any of them, so an encoding error stores nothing
+func (w *compressionDecorator[K, V]) SetMany(entries map[K]V) error {
+	encoded := make(map[K][]byte, len(entries))
+	for key, value := range entries {
+		rawBytes, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		w.events.Emit(cache.Event[K, V]{
+			Type: cache.EventTypeReadBytes,
+			Key:  key,
+			Size: len(rawBytes),
+		})
+
+		compressedBytes, err := compressRaw(rawBytes)
+		if err != nil {
+			return err
+		}
+		w.events.Emit(cache.Event[K, V]{
+			Type: cache.EventTypeCompressBytes,
+			Key:  key,
+			Size: len(compressedBytes),
+		})
+		encoded[key] = compressedBytes
+	}
+	return cache.SetMany(w.cacheWrappee, encoded)
+}
+
+func (w *compressionDecorator[K, V]) DeleteMany(keys []K) int {
+	return cache.DeleteMany(w.cacheWrappee, keys)
+}
+
 func (w *compressionDecorator[K, V]) Clear() {
 	w.cacheWrappee.Clear()
 }
@@ -107,14 +188,8 @@ func decompressRaw(data []byte) (b []byte, err error) {
 	return io.ReadAll(gr)
 }
 
-func (w *compressionDecorator[K, V]) OnEvent(callback func(cache.Event[K, V])) {
-	w.eventCallbacks = append(w.eventCallbacks, callback)
-}
-
-func (w *compressionDecorator[K, V]) emit(event cache.Event[K, V]) {
-	for _, callback := range w.eventCallbacks {
-		callback(event)
-	}
+func (w *compressionDecorator[K, V]) OnEvent(callback func(cache.Event[K, V])) cache.Subscription {
+	return w.events.Subscribe(callback)
 }
 
 func (m *compressionDecorator[K, V]) Range(fn func(K, V) bool) {
@@ -0,0 +1,73 @@
+package decorators
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextAdapters(t *testing.T) {
+	baseCache := strategies.NewLruCache[string, int](10)()
+	ctxCache := cache.ToContextCache(baseCache)
+
+	require.NoError(t, ctxCache.SetCtx(context.Background(), "a", 1))
+	val, err := ctxCache.GetCtx(context.Background(), "a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ctxCache.GetCtx(cancelled, "a")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.ErrorIs(t, ctxCache.SetCtx(cancelled, "b", 2), context.Canceled)
+	assert.ErrorIs(t, ctxCache.DeleteCtx(cancelled, "a"), context.Canceled)
+
+	plain := cache.FromContextCache(ctxCache)
+	val, err = plain.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	metricsCache := WithMetrics(baseCache)
+	assert.Same(t, metricsCache, cache.ToContextCache[string, int](metricsCache),
+		"decorators implementing ContextCache should not be wrapped again")
+}
+
+func TestDecorators_HonorCancellation(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	decorated := map[string]cache.Cache[string, int]{
+		"metrics": WithMetrics(strategies.NewLruCache[string, int](10)()),
+		"logging": WithDebugLogging(strategies.NewLruCache[string, int](10)(), logger),
+		"bloom":   WithBloomFilter(strategies.NewLruCache[string, int](10)(), 10, 0.01),
+		"loader": WithLoader(strategies.NewLruCache[string, int](10)(), func(context.Context, string) (int, error) {
+			return 0, common.ErrKeyNotFound
+		}),
+		"compression": WithCompression[string, int](strategies.NewLruCache[string, []byte](10)(), nil),
+	}
+
+	for name, c := range decorated {
+		t.Run(name, func(t *testing.T) {
+			ctxCache, ok := c.(cache.ContextCache[string, int])
+			require.True(t, ok, "decorator should implement ContextCache")
+
+			assert.ErrorIs(t, ctxCache.SetCtx(cancelled, "key", 1), context.Canceled)
+			_, err := c.Get("key")
+			assert.Error(t, err, "cancelled Set should not store the value")
+
+			require.NoError(t, c.Set("key", 1))
+			_, err = ctxCache.GetCtx(cancelled, "key")
+			assert.ErrorIs(t, err, context.Canceled)
+		})
+	}
+}
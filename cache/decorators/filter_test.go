@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/kimvlry/caching/cache/clock/clocktest"
 	"github.com/kimvlry/caching/cache/strategies"
 )
 
@@ -258,6 +259,7 @@ func TestWithFilter_DifferentEvictionStrategy_LFU(t *testing.T) {
 }
 
 func TestWithFilter_DifferentEvictionStrategy_TTL(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
 	baseCache := strategies.NewLruCache[string, int](10)()
 	_ = baseCache.Set("key1", 10)
 	_ = baseCache.Set("key2", 20)
@@ -266,7 +268,7 @@ func TestWithFilter_DifferentEvictionStrategy_TTL(t *testing.T) {
 	filtered := WithFilter(
 		baseCache,
 		func(v int) bool { return v >= 20 },
-		strategies.NewTtlCache[string, int](10, 200*time.Millisecond),
+		strategies.NewTtlCache[string, int](10, 200*time.Millisecond, strategies.WithClock[string, int](clk)),
 	)
 
 	val, err := filtered.Get("key2")
@@ -283,7 +285,7 @@ func TestWithFilter_DifferentEvictionStrategy_TTL(t *testing.T) {
 		t.Error("key1 should be filtered out")
 	}
 
-	time.Sleep(250 * time.Millisecond)
+	clk.Advance(200 * time.Millisecond)
 
 	_, err = filtered.Get("key2")
 	if err == nil {
@@ -0,0 +1,163 @@
+package decorators
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+)
+
+// Loader fetches the value of a key that is missing from the cache, e.g. from a database
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// LoadingCache is a read-through cache: misses are filled by its Loader
+type LoadingCache[K comparable, V any] interface {
+	cache.Cache[K, V]
+	GetOrLoad(ctx context.Context, key K) (V, error)
+}
+
+var errLoadPanicked = errors.New("loader panicked")
+
+type loaderDecorator[K comparable, V any] struct {
+	cacheWrappee cache.Cache[K, V]
+	ctxWrappee   cache.ContextCache[K, V]
+	loader       Loader[K, V]
+
+	mu       sync.Mutex
+	inFlight map[K]*loadCall[V]
+}
+
+// loadCall is a loader invocation shared by every caller that missed the same key while it was running
+type loadCall[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+
+	// invalidated is set by a write, delete or clear of the key while the loader runs, the loaded value is then stale
+	invalidated bool
+}
+
+// WithLoader creates a read-through decorator for the given cache.
+// Concurrent misses for the same key are collapsed into a single loader call whose result is stored in the cache
+// and handed to every waiter. Loader errors are returned to all waiters and are never cached.
+// A Set, Delete or Clear going through the decorator while the loader runs wins over the loaded value,
+// which is then handed to the waiters but not stored.
+//
+// The loader runs with the values of the context of the caller that triggered it, but is not cancelled with it,
+// since other callers may be waiting for its result. That caller waits for the loader to return,
+// other waiters stop waiting as soon as their own context is done.
+func WithLoader[K comparable, V any](wrappee cache.Cache[K, V], loader Loader[K, V]) LoadingCache[K, V] {
+	return &loaderDecorator[K, V]{
+		cacheWrappee: wrappee,
+		ctxWrappee:   cache.ToContextCache(wrappee),
+		loader:       loader,
+		inFlight:     make(map[K]*loadCall[V]),
+	}
+}
+
+// Get is GetOrLoad with a background context
+func (l *loaderDecorator[K, V]) Get(key K) (V, error) {
+	return l.GetOrLoad(context.Background(), key)
+}
+
+// GetCtx is GetOrLoad
+func (l *loaderDecorator[K, V]) GetCtx(ctx context.Context, key K) (V, error) {
+	return l.GetOrLoad(ctx, key)
+}
+
+func (l *loaderDecorator[K, V]) GetOrLoad(ctx context.Context, key K) (V, error) {
+	value, err := l.ctxWrappee.GetCtx(ctx, key)
+	if !errors.Is(err, common.ErrKeyNotFound) {
+		return value, err
+	}
+
+	l.mu.Lock()
+	call, exists := l.inFlight[key]
+	if !exists {
+		call = &loadCall[V]{done: make(chan struct{})}
+		l.inFlight[key] = call
+	}
+	l.mu.Unlock()
+
+	if !exists {
+		l.load(ctx, key, call)
+	}
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// load runs the loader and stores a successful result, unless the key was invalidated meanwhile.
+// A failed store is not reported: the value is still valid, the next Get simply loads it again.
+func (l *loaderDecorator[K, V]) load(ctx context.Context, key K, call *loadCall[V]) {
+	ctx = context.WithoutCancel(ctx)
+	call.err = errLoadPanicked
+	defer close(call.done)
+	defer func() {
+		l.mu.Lock()
+		delete(l.inFlight, key)
+		l.mu.Unlock()
+	}()
+
+	call.value, call.err = l.loader(ctx, key)
+	if call.err != nil {
+		return
+	}
+	// Storing under the lock orders it with invalidate: a write either comes after the store or is seen by it
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !call.invalidated {
+		_ = l.ctxWrappee.SetCtx(ctx, key, call.value)
+	}
+}
+
+// invalidate keeps an in-flight load of key from storing its value over a write or delete about to happen
+func (l *loaderDecorator[K, V]) invalidate(key K) {
+	l.mu.Lock()
+	if call, exists := l.inFlight[key]; exists {
+		call.invalidated = true
+	}
+	l.mu.Unlock()
+}
+
+func (l *loaderDecorator[K, V]) Set(key K, value V) error {
+	l.invalidate(key)
+	return l.cacheWrappee.Set(key, value)
+}
+
+func (l *loaderDecorator[K, V]) SetCtx(ctx context.Context, key K, value V) error {
+	l.invalidate(key)
+	return l.ctxWrappee.SetCtx(ctx, key, value)
+}
+
+func (l *loaderDecorator[K, V]) Delete(key K) error {
+	l.invalidate(key)
+	return l.cacheWrappee.Delete(key)
+}
+
+func (l *loaderDecorator[K, V]) DeleteCtx(ctx context.Context, key K) error {
+	l.invalidate(key)
+	return l.ctxWrappee.DeleteCtx(ctx, key)
+}
+
+func (l *loaderDecorator[K, V]) Clear() {
+	l.mu.Lock()
+	for _, call := range l.inFlight {
+		call.invalidated = true
+	}
+	l.mu.Unlock()
+	l.cacheWrappee.Clear()
+}
+
+func (l *loaderDecorator[K, V]) Range(fn func(K, V) bool) {
+	if iterable, ok := any(l.cacheWrappee).(cache.IterableCache[K, V]); ok {
+		iterable.Range(fn)
+	}
+}
@@ -0,0 +1,204 @@
+package decorators
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoaderDecorator_LoadsAndStoresMisses(t *testing.T) {
+	baseCache := strategies.NewLruCache[string, int](10)()
+	var calls atomic.Int64
+	loadingCache := WithLoader(baseCache, func(_ context.Context, key string) (int, error) {
+		calls.Add(1)
+		return len(key), nil
+	})
+
+	val, err := loadingCache.GetOrLoad(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, 3, val)
+
+	stored, err := baseCache.Get("abc")
+	require.NoError(t, err, "loaded value should be stored in the wrapped cache")
+	assert.Equal(t, 3, stored)
+
+	val, err = loadingCache.Get("abc")
+	require.NoError(t, err)
+	assert.Equal(t, 3, val)
+	assert.Equal(t, int64(1), calls.Load(), "a hit should not call the loader")
+}
+
+// waitingContext calls onWait the first time its Done channel is asked for,
+// which GetOrLoad only does once the caller waits for a load
+type waitingContext struct {
+	context.Context
+	once   sync.Once
+	onWait func()
+}
+
+func (c *waitingContext) Done() <-chan struct{} {
+	c.once.Do(c.onWait)
+	return c.Context.Done()
+}
+
+func TestLoaderDecorator_DeduplicatesConcurrentMisses(t *testing.T) {
+	baseCache := strategies.NewLruCache[string, int](10, strategies.WithConcurrency[string, int]())()
+	release := make(chan struct{})
+	var calls atomic.Int64
+	loadingCache := WithLoader(baseCache, func(_ context.Context, key string) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	})
+
+	const callers = 20
+	// The caller running the loader waits once it returns, every other caller waits for it
+	var waiting atomic.Int64
+	allWaiting := make(chan struct{})
+	onWait := func() {
+		if waiting.Add(1) == callers-1 {
+			close(allWaiting)
+		}
+	}
+
+	var finished sync.WaitGroup
+	results := make([]int, callers)
+	for i := 0; i < callers; i++ {
+		finished.Add(1)
+		go func(i int) {
+			defer finished.Done()
+			ctx := &waitingContext{Context: context.Background(), onWait: onWait}
+			val, err := loadingCache.GetOrLoad(ctx, "hot")
+			assert.NoError(t, err)
+			results[i] = val
+		}(i)
+	}
+	<-allWaiting
+	close(release)
+	finished.Wait()
+
+	assert.Equal(t, int64(1), calls.Load(), "concurrent misses should share one loader call")
+	for _, val := range results {
+		assert.Equal(t, 42, val)
+	}
+}
+
+func TestLoaderDecorator_ErrorsAreNotCached(t *testing.T) {
+	baseCache := strategies.NewLruCache[string, int](10)()
+	errDatabase := errors.New("database is down")
+	fail := true
+	loadingCache := WithLoader(baseCache, func(context.Context, string) (int, error) {
+		if fail {
+			return 0, errDatabase
+		}
+		return 7, nil
+	})
+
+	_, err := loadingCache.Get("key")
+	assert.ErrorIs(t, err, errDatabase)
+
+	_, err = baseCache.Get("key")
+	assert.Error(t, err, "failed loads should not be stored")
+
+	fail = false
+	val, err := loadingCache.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, 7, val)
+}
+
+func TestLoaderDecorator_InvalidationWinsOverLoad(t *testing.T) {
+	for name, invalidate := range map[string]func(LoadingCache[string, int]){
+		"delete": func(c LoadingCache[string, int]) { _ = c.Delete("key") },
+		"set":    func(c LoadingCache[string, int]) { _ = c.Set("key", 2) },
+		"clear":  func(c LoadingCache[string, int]) { c.Clear() },
+	} {
+		t.Run(name, func(t *testing.T) {
+			baseCache := strategies.NewLruCache[string, int](10, strategies.WithConcurrency[string, int]())()
+			release := make(chan struct{})
+			loading := make(chan struct{})
+			loadingCache := WithLoader(baseCache, func(context.Context, string) (int, error) {
+				close(loading)
+				<-release
+				return 1, nil
+			})
+
+			loaded := make(chan int)
+			go func() {
+				val, _ := loadingCache.GetOrLoad(context.Background(), "key")
+				loaded <- val
+			}()
+			<-loading
+			require.NoError(t, baseCache.Set("key", 3))
+			invalidate(loadingCache)
+			close(release)
+			assert.Equal(t, 1, <-loaded, "waiters still get the loaded value")
+
+			val, err := baseCache.Get("key")
+			if name == "set" {
+				require.NoError(t, err)
+				assert.Equal(t, 2, val, "the loaded value should not overwrite a later write")
+			} else {
+				assert.Error(t, err, "the loaded value should not come back after an invalidation")
+			}
+		})
+	}
+}
+
+func TestLoaderDecorator_LoadOutlivesTriggeringCaller(t *testing.T) {
+	baseCache := strategies.NewLruCache[string, int](10, strategies.WithConcurrency[string, int]())()
+	release := make(chan struct{})
+	loading := make(chan struct{})
+	loadingCache := WithLoader(baseCache, func(ctx context.Context, _ string) (int, error) {
+		close(loading)
+		<-release
+		return 1, ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		_, _ = loadingCache.GetOrLoad(ctx, "key")
+	}()
+	<-loading
+
+	waiter := &waitingContext{Context: context.Background(), onWait: func() {
+		cancel()
+		close(release)
+	}}
+	val, err := loadingCache.GetOrLoad(waiter, "key")
+	require.NoError(t, err, "the load should not be cancelled with the caller that triggered it")
+	assert.Equal(t, 1, val)
+
+	stored, err := baseCache.Get("key")
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored)
+}
+
+func TestLoaderDecorator_WaiterContextCancellation(t *testing.T) {
+	baseCache := strategies.NewLruCache[string, int](10, strategies.WithConcurrency[string, int]())()
+	release := make(chan struct{})
+	loading := make(chan struct{})
+	loadingCache := WithLoader(baseCache, func(context.Context, string) (int, error) {
+		close(loading)
+		<-release
+		return 1, nil
+	})
+
+	go func() {
+		_, _ = loadingCache.GetOrLoad(context.Background(), "slow")
+	}()
+	<-loading
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := loadingCache.GetOrLoad(ctx, "slow")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(release)
+}
@@ -1,6 +1,7 @@
 package decorators
 
 import (
+	"context"
 	"fmt"
 	"github.com/kimvlry/caching/cache"
 	"log/slog"
@@ -8,47 +9,85 @@ import (
 
 type loggingDecorator[K comparable, V any] struct {
 	cacheWrappee cache.Cache[K, V]
+	ctxWrappee   cache.ContextCache[K, V]
 	logger       *slog.Logger
 }
 
-func WithDebugLogging[K comparable, V any](cache cache.Cache[K, V], logger *slog.Logger) cache.Cache[K, V] {
-	logger = logger.With("cache", fmt.Sprintf("%T", cache))
+func WithDebugLogging[K comparable, V any](wrappee cache.Cache[K, V], logger *slog.Logger) cache.Cache[K, V] {
+	logger = logger.With("cache", fmt.Sprintf("%T", wrappee))
 	return &loggingDecorator[K, V]{
-		cacheWrappee: cache,
+		cacheWrappee: wrappee,
+		ctxWrappee:   cache.ToContextCache(wrappee),
 		logger:       logger,
 	}
 }
 
 func (w *loggingDecorator[K, V]) Get(key K) (V, error) {
-	w.logger.Debug("Get method called", "key", key)
-	val, err := w.cacheWrappee.Get(key)
+	return w.GetCtx(context.Background(), key)
+}
+
+// GetCtx logs through the context-aware slog methods, so handlers can attach trace data carried by ctx
+func (w *loggingDecorator[K, V]) GetCtx(ctx context.Context, key K) (V, error) {
+	w.logger.DebugContext(ctx, "Get method called", "key", key)
+	val, err := w.ctxWrappee.GetCtx(ctx, key)
 	if err != nil {
-		w.logger.Warn("Get method returned an error", "key", key, "err", err)
+		w.logger.WarnContext(ctx, "Get method returned an error", "key", key, "err", err)
 	}
-	w.logger.Debug("Get method returned a value", "key", key, "val", val)
+	w.logger.DebugContext(ctx, "Get method returned a value", "key", key, "val", val)
 	return val, err
 }
 
 func (w *loggingDecorator[K, V]) Set(key K, value V) error {
-	w.logger.Debug("Set method called", "key", key)
-	err := w.cacheWrappee.Set(key, value)
+	return w.SetCtx(context.Background(), key, value)
+}
+
+func (w *loggingDecorator[K, V]) SetCtx(ctx context.Context, key K, value V) error {
+	w.logger.DebugContext(ctx, "Set method called", "key", key)
+	err := w.ctxWrappee.SetCtx(ctx, key, value)
 	if err != nil {
-		w.logger.Warn("Set method returned an error", "key", key, "err", err)
+		w.logger.WarnContext(ctx, "Set method returned an error", "key", key, "err", err)
 	}
-	w.logger.Debug("Set method returned a value", "key", key, "val", value)
+	w.logger.DebugContext(ctx, "Set method returned a value", "key", key, "val", value)
 	return err
 }
 
 func (w *loggingDecorator[K, V]) Delete(key K) error {
-	w.logger.Debug("Delete method called", "key", key)
-	err := w.cacheWrappee.Delete(key)
+	return w.DeleteCtx(context.Background(), key)
+}
+
+func (w *loggingDecorator[K, V]) DeleteCtx(ctx context.Context, key K) error {
+	w.logger.DebugContext(ctx, "Delete method called", "key", key)
+	err := w.ctxWrappee.DeleteCtx(ctx, key)
 	if err != nil {
-		w.logger.Warn("Delete method returned an error", "key", key, "err", err)
+		w.logger.WarnContext(ctx, "Delete method returned an error", "key", key, "err", err)
 	}
-	w.logger.Debug("Delete method returned a value", "key", key, "val", key)
+	w.logger.DebugContext(ctx, "Delete method returned a value", "key", key, "val", key)
 	return err
 }
 
+func (w *loggingDecorator[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	w.logger.Debug("GetMany method called", "keys", keys)
+	found, missing := cache.GetMany(w.cacheWrappee, keys)
+	w.logger.Debug("GetMany method returned values", "found", len(found), "missing", missing)
+	return found, missing
+}
+
+func (w *loggingDecorator[K, V]) SetMany(entries map[K]V) error {
+	w.logger.Debug("SetMany method called", "entries", len(entries))
+	err := cache.SetMany(w.cacheWrappee, entries)
+	if err != nil {
+		w.logger.Warn("SetMany method returned an error", "err", err)
+	}
+	return err
+}
+
+func (w *loggingDecorator[K, V]) DeleteMany(keys []K) int {
+	w.logger.Debug("DeleteMany method called", "keys", keys)
+	deleted := cache.DeleteMany(w.cacheWrappee, keys)
+	w.logger.Debug("DeleteMany method returned a value", "deleted", deleted)
+	return deleted
+}
+
 func (w *loggingDecorator[K, V]) Clear() {
 	w.logger.Debug("Clear method called")
 	w.cacheWrappee.Clear()
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/kimvlry/caching/cache/clock/clocktest"
 	"github.com/kimvlry/caching/cache/strategies"
 )
 
@@ -115,6 +116,7 @@ func TestWithMap_DifferentEvictionStrategy_LFU(t *testing.T) {
 }
 
 func TestWithMap_DifferentEvictionStrategy_TTL(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
 	baseCache := strategies.NewLruCache[string, int](10)()
 	_ = baseCache.Set("x", 5)
 	_ = baseCache.Set("y", 10)
@@ -123,7 +125,7 @@ func TestWithMap_DifferentEvictionStrategy_TTL(t *testing.T) {
 	mapped := WithMap(
 		baseCache,
 		func(v int) int { return v + 100 },
-		strategies.NewTtlCache[string, int](10, 150*time.Millisecond),
+		strategies.NewTtlCache[string, int](10, 150*time.Millisecond, strategies.WithClock[string, int](clk)),
 	)
 
 	val, err := mapped.Get("x")
@@ -136,7 +138,7 @@ func TestWithMap_DifferentEvictionStrategy_TTL(t *testing.T) {
 		t.Errorf("y expected 110, got %d", val)
 	}
 
-	time.Sleep(200 * time.Millisecond)
+	clk.Advance(150 * time.Millisecond)
 
 	_, err = mapped.Get("x")
 	if err == nil {
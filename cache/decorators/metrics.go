@@ -1,6 +1,7 @@
 package decorators
 
 import (
+	"context"
 	"errors"
 	"github.com/kimvlry/caching/cache"
 	"github.com/kimvlry/caching/cache/strategies/common"
@@ -9,6 +10,7 @@ import (
 
 type metricsDecorator[K comparable, V any] struct {
 	cacheWrappee cache.Cache[K, V]
+	ctxWrappee   cache.ContextCache[K, V]
 
 	hits   atomic.Int64
 	misses atomic.Int64
@@ -29,6 +31,7 @@ type AwareCache[K comparable, V any] interface {
 func WithMetrics[K comparable, V any](wrappee cache.Cache[K, V]) AwareCache[K, V] {
 	decorator := &metricsDecorator[K, V]{
 		cacheWrappee: wrappee,
+		ctxWrappee:   cache.ToContextCache(wrappee),
 	}
 	if observable, ok := any(wrappee).(cache.ObservableCache[K, V]); ok {
 		observable.OnEvent(func(event cache.Event[K, V]) {
@@ -69,7 +72,11 @@ func (m *metricsDecorator[K, V]) GetEvictions() int64 {
 }
 
 func (m *metricsDecorator[K, V]) Get(key K) (V, error) {
-	v, err := m.cacheWrappee.Get(key)
+	return m.GetCtx(context.Background(), key)
+}
+
+func (m *metricsDecorator[K, V]) GetCtx(ctx context.Context, key K) (V, error) {
+	v, err := m.ctxWrappee.GetCtx(ctx, key)
 	if err == nil {
 		m.hits.Add(1)
 	}
@@ -83,14 +90,39 @@ func (m *metricsDecorator[K, V]) Set(key K, value V) error {
 	return m.cacheWrappee.Set(key, value)
 }
 
+func (m *metricsDecorator[K, V]) SetCtx(ctx context.Context, key K, value V) error {
+	return m.ctxWrappee.SetCtx(ctx, key, value)
+}
+
 func (m *metricsDecorator[K, V]) Delete(key K) error {
-	err := m.cacheWrappee.Delete(key)
+	return m.DeleteCtx(context.Background(), key)
+}
+
+func (m *metricsDecorator[K, V]) DeleteCtx(ctx context.Context, key K) error {
+	err := m.ctxWrappee.DeleteCtx(ctx, key)
 	if errors.Is(err, common.ErrKeyNotFound) {
 		m.misses.Add(1)
 	}
 	return err
 }
 
+func (m *metricsDecorator[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	found, missing := cache.GetMany(m.cacheWrappee, keys)
+	m.hits.Add(int64(len(found)))
+	m.misses.Add(int64(len(missing)))
+	return found, missing
+}
+
+func (m *metricsDecorator[K, V]) SetMany(entries map[K]V) error {
+	return cache.SetMany(m.cacheWrappee, entries)
+}
+
+func (m *metricsDecorator[K, V]) DeleteMany(keys []K) int {
+	deleted := cache.DeleteMany(m.cacheWrappee, keys)
+	m.misses.Add(int64(len(keys) - deleted))
+	return deleted
+}
+
 func (m *metricsDecorator[K, V]) Clear() {
 	m.cacheWrappee.Clear()
 }
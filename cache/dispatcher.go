@@ -0,0 +1,158 @@
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Subscription is returned by OnEvent; Unsubscribe detaches the listener and is safe to call more than once
+type Subscription interface {
+	Unsubscribe()
+}
+
+// OverflowPolicy decides what an asynchronous EventDispatcher does with an event when its buffer is full
+type OverflowPolicy int
+
+const (
+	// OverflowDrop discards the event and counts it in Dropped, so a slow listener never slows the cache down
+	OverflowDrop OverflowPolicy = iota
+	// OverflowBlock makes the emitting cache operation wait for room in the buffer, so no event is lost.
+	// Listeners must not call into a cache that emits through the same dispatcher, not even to read from it:
+	// Get, GetMany and Range emit hits, misses and lazy expirations while holding the cache lock,
+	// so a listener waiting for that lock while the buffer is full waits on itself.
+	OverflowBlock
+)
+
+// EventDispatcher delivers the events of one or more caches to their listeners.
+//
+// A synchronous dispatcher calls listeners inside the cache operation that emitted the event,
+// while the cache holds its lock. An asynchronous dispatcher queues events and calls listeners,
+// in emission order, from a single background goroutine that only runs while the queue is not empty;
+// listeners are then free to call back into the cache, unless the dispatcher blocks on overflow.
+//
+// Caches sharing a dispatcher share its listeners: subscribing through any of them observes all of them.
+type EventDispatcher[K comparable, V any] struct {
+	mu        sync.Mutex
+	listeners atomic.Pointer[[]*listener[K, V]]
+
+	queue   chan Event[K, V]
+	policy  OverflowPolicy
+	dropped atomic.Int64
+	running atomic.Bool
+}
+
+type listener[K comparable, V any] struct {
+	callback func(Event[K, V])
+}
+
+type subscription[K comparable, V any] struct {
+	dispatcher *EventDispatcher[K, V]
+	listener   *listener[K, V]
+}
+
+// NewEventDispatcher returns a synchronous dispatcher
+func NewEventDispatcher[K comparable, V any]() *EventDispatcher[K, V] {
+	return &EventDispatcher[K, V]{}
+}
+
+// NewAsyncEventDispatcher returns a dispatcher that buffers up to bufferSize events
+// and applies policy once the buffer is full
+func NewAsyncEventDispatcher[K comparable, V any](bufferSize int, policy OverflowPolicy) *EventDispatcher[K, V] {
+	return &EventDispatcher[K, V]{
+		queue:  make(chan Event[K, V], max(bufferSize, 1)),
+		policy: policy,
+	}
+}
+
+// Subscribe registers callback for every event emitted from now on
+func (d *EventDispatcher[K, V]) Subscribe(callback func(Event[K, V])) Subscription {
+	l := &listener[K, V]{callback: callback}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var current []*listener[K, V]
+	if loaded := d.listeners.Load(); loaded != nil {
+		current = *loaded
+	}
+	updated := make([]*listener[K, V], len(current), len(current)+1)
+	copy(updated, current)
+	updated = append(updated, l)
+	d.listeners.Store(&updated)
+
+	return &subscription[K, V]{dispatcher: d, listener: l}
+}
+
+func (s *subscription[K, V]) Unsubscribe() {
+	d := s.dispatcher
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	loaded := d.listeners.Load()
+	if loaded == nil {
+		return
+	}
+	updated := make([]*listener[K, V], 0, len(*loaded))
+	for _, l := range *loaded {
+		if l != s.listener {
+			updated = append(updated, l)
+		}
+	}
+	d.listeners.Store(&updated)
+}
+
+// Emit hands event to the listeners, directly or through the queue
+func (d *EventDispatcher[K, V]) Emit(event Event[K, V]) {
+	if d.queue == nil {
+		d.deliver(event)
+		return
+	}
+	if loaded := d.listeners.Load(); loaded == nil || len(*loaded) == 0 {
+		return
+	}
+
+	switch d.policy {
+	case OverflowBlock:
+		d.queue <- event
+	default:
+		select {
+		case d.queue <- event:
+		default:
+			d.dropped.Add(1)
+			return
+		}
+	}
+	if d.running.CompareAndSwap(false, true) {
+		go d.drain()
+	}
+}
+
+// Dropped reports how many events an OverflowDrop dispatcher has discarded
+func (d *EventDispatcher[K, V]) Dropped() int64 {
+	return d.dropped.Load()
+}
+
+func (d *EventDispatcher[K, V]) deliver(event Event[K, V]) {
+	loaded := d.listeners.Load()
+	if loaded == nil {
+		return
+	}
+	for _, l := range *loaded {
+		l.callback(event)
+	}
+}
+
+// drain delivers queued events until the queue is empty.
+// The second check catches events queued after the queue looked empty but before running was reset.
+func (d *EventDispatcher[K, V]) drain() {
+	for {
+		select {
+		case event := <-d.queue:
+			d.deliver(event)
+		default:
+			d.running.Store(false)
+			if len(d.queue) == 0 || !d.running.CompareAndSwap(false, true) {
+				return
+			}
+		}
+	}
+}
@@ -2,7 +2,7 @@ package cache
 
 type ObservableCache[K comparable, V any] interface {
 	Cache[K, V]
-	OnEvent(callback func(event Event[K, V]))
+	OnEvent(callback func(event Event[K, V])) Subscription
 }
 
 type EventType string
@@ -11,11 +11,44 @@ const (
 	EventTypeEviction      EventType = "eviction"
 	EventTypeReadBytes     EventType = "write raw bytes"
 	EventTypeCompressBytes EventType = "compress bytes"
+
+	EventTypeHit    EventType = "hit"
+	EventTypeMiss   EventType = "miss"
+	EventTypeInsert EventType = "insert"
+	EventTypeUpdate EventType = "update"
+	EventTypeDelete EventType = "delete"
+	EventTypeClear  EventType = "clear"
+
+	// EventTypeDemotion reports an entry pushed out of a protected segment by the promotion of another one.
+	// Unlike an eviction, the entry stays cached, one step closer to eviction.
+	EventTypeDemotion EventType = "demotion"
+
+	// EventTypeRefresh reports a value reloaded in the background by a refresh policy, replacing the cached one
+	EventTypeRefresh EventType = "refresh"
+	// EventTypeRefreshFailure reports a background reload that failed; Value is the value still cached
+	EventTypeRefreshFailure EventType = "refresh failure"
+)
+
+// EvictionReason tells listeners of EventTypeEviction why the cache dropped an entry on its own.
+// Expiry and capacity evictions share the event type so that a listener counting or cleaning up after every
+// eviction needs a single case, and a new reason does not go unnoticed by listeners written before it.
+type EvictionReason string
+
+const (
+	// EvictionReasonCapacity means the entry was chosen by the eviction policy to make room for another one
+	EvictionReasonCapacity EvictionReason = "capacity"
+	// EvictionReasonExpired means the entry outlived its time to live
+	EvictionReasonExpired EvictionReason = "expired"
 )
 
+// Event describes something that happened to a cache.
+// Key is unset for EventTypeClear, Value is the zero value for misses, Reason is only set for evictions
+// and Err only for refresh failures.
 type Event[K comparable, V any] struct {
-	Type  EventType
-	Key   K
-	Value V
-	Size  int
+	Type   EventType
+	Key    K
+	Value  V
+	Size   int
+	Reason EvictionReason
+	Err    error
 }
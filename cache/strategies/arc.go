@@ -2,95 +2,174 @@ package strategies
 
 import (
 	"container/list"
+	"errors"
 	"github.com/kimvlry/caching/cache"
 	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+	"time"
 )
 
-// ARCCache implements Adaptive Replacement Cache algorithm
+// ARCCache implements the Adaptive Replacement Cache algorithm of Megiddo and Modha.
+// T1 holds entries used once recently and T2 entries used at least twice; B1 and B2 remember the keys recently
+// evicted from each. A hit in B1 grows p, the target size of T1, and a hit in B2 shrinks it. Ghosts are trimmed so that
+// |T1|+|B1| <= c and |T1|+|T2|+|B1|+|B2| <= 2c, all sizes measured in weight.
 type ARCCache[K comparable, V any] struct {
 	capacity int
-	p        int // adaptive parameter (target size for T1)
+	p        int64 // adaptive parameter (target size for T1)
+	weigher  weigher[K, V]
 
 	t1 *cacheList[K, V] // recently used once
 	t2 *cacheList[K, V] // frequently used
 	b1 *cacheList[K, V] // ghost list for T1
 	b2 *cacheList[K, V] // ghost list for T2
 
-	eventCallbacks []func(cache.Event[K, V])
+	expiry *expiry[K, V]
+	mu     sync.Locker
+
+	events *cache.EventDispatcher[K, V]
+}
+
+// ARCState is a snapshot of the adaptive target and the list sizes of an ARCCache, in weight
+type ARCState struct {
+	P              int64 // target size of T1
+	T1, T2, B1, B2 int64
 }
 
 type ghostEntry[K comparable] struct {
-	key K
+	key    K
+	weight int64
 }
 
 type cacheList[K comparable, V any] struct {
 	l       *list.List
 	m       map[K]*list.Element
+	size    int64 // total weight of the entries
 	isGhost bool
 }
 
-func newArcCache[K comparable, V any](capacity int) cache.IterableCache[K, V] {
+func newArcCache[K comparable, V any](capacity int, opts ...Option[K, V]) cache.IterableCache[K, V] {
 	if capacity <= 0 {
 		capacity = 1
 	}
-	return &ARCCache[K, V]{
+	cfg := newConfig(opts)
+	a := &ARCCache[K, V]{
 		capacity: capacity,
+		weigher:  cfg.weigher,
 		t1:       newCacheList[K, V](false),
 		t2:       newCacheList[K, V](false),
 		b1:       newCacheList[K, V](true),
 		b2:       newCacheList[K, V](true),
+		expiry:   newExpiry(cfg),
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
 	}
+	a.expiry.startCleaner(cfg, a.purgeExpired)
+	return a
 }
 
 func (a *ARCCache[K, V]) Get(key K) (V, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.get(key)
+}
+
+func (a *ARCCache[K, V]) get(key K) (V, error) {
+	if a.expiry.expired(key) {
+		a.expire(key)
+	}
 	if elem, ok := a.t1.m[key]; ok {
 		e := elem.Value.(*entry[K, V])
 		a.t1.remove(key)
-		a.t2.addFront(key, e.value)
+		a.t2.addFront(key, e.value, e.weight)
+		a.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: e.value})
 		return e.value, nil
 	}
 	if elem, ok := a.t2.m[key]; ok {
 		a.t2.l.MoveToFront(elem)
 		e := elem.Value.(*entry[K, V])
+		a.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: e.value})
 		return e.value, nil
 	}
+	a.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
 	var zero V
 	return zero, common.ErrKeyNotFound
 }
 
 func (a *ARCCache[K, V]) Set(key K, value V) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.set(key, value); err != nil {
+		return err
+	}
+	a.expiry.trackDefault(key)
+	return nil
+}
+
+// SetWithTTL stores the value and lets it expire after ttl, whichever list holds it
+func (a *ARCCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.set(key, value); err != nil {
+		return err
+	}
+	a.expiry.track(key, ttl)
+	return nil
+}
+
+func (a *ARCCache[K, V]) GetDefaultTTL() time.Duration {
+	return a.expiry.defaultTTL
+}
+
+func (a *ARCCache[K, V]) set(key K, value V) error {
+	weight, err := a.weigher.admit(key, value, a.capacity)
+	if err != nil {
+		return err
+	}
 	switch {
 	case a.t1.m[key] != nil:
 		a.t1.remove(key)
-		a.t2.addFront(key, value)
-		return nil
+		a.t2.addFront(key, value, weight)
+		a.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+		// The new value may weigh more than the old one
+		a.makeRoom(0, false)
 	case a.t2.m[key] != nil:
-		a.t2.moveToFront(key, value)
-		return nil
+		a.t2.moveToFront(key, value, weight)
+		a.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+		a.makeRoom(0, false)
 	case a.b1.m[key] != nil:
-		a.adapt(true)
+		a.adapt(true, weight)
 		a.b1.remove(key)
-		a.replace(key, true)
-		a.t2.addFront(key, value)
-		return nil
+		a.makeRoom(weight, false)
+		a.t2.addFront(key, value, weight)
+		a.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
 	case a.b2.m[key] != nil:
-		a.adapt(false)
+		a.adapt(false, weight)
 		a.b2.remove(key)
-		a.replace(key, false)
-		a.t2.addFront(key, value)
-		return nil
+		a.makeRoom(weight, true)
+		a.t2.addFront(key, value, weight)
+		a.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
 	default:
-		if a.t1.len()+a.t2.len() >= a.capacity {
-			a.replace(key, false)
-		}
-		a.t1.addFront(key, value)
-		return nil
+		a.makeRoom(weight, false)
+		a.t1.addFront(key, value, weight)
+		a.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
 	}
+	trimGhosts(int64(a.capacity), a.t1.size, a.t2.size, a.b1, a.b2)
+	return nil
 }
 
-// replace decides which list to evict from
-func (a *ARCCache[K, V]) replace(key K, inB1 bool) {
-	if a.t1.len() > 0 && (a.t1.len() > a.p || (inB1 && a.t1.len() == a.p)) {
+// makeRoom replaces resident entries until weight more fits into the capacity
+func (a *ARCCache[K, V]) makeRoom(weight int64, inB2 bool) {
+	for a.t1.size+a.t2.size+weight > int64(a.capacity) && a.t1.len()+a.t2.len() > 0 {
+		a.replace(inB2)
+	}
+}
+
+// replace evicts the least recently used entry of T1 if T1 is over its target, or at it on a hit in B2,
+// and that of T2 otherwise
+func (a *ARCCache[K, V]) replace(inB2 bool) {
+	if a.t1.len() > 0 && (a.t1.size > a.p || (inB2 && a.t1.size == a.p) || a.t2.len() == 0) {
 		a.evictFromList(a.t1, a.b1)
 	} else {
 		a.evictFromList(a.t2, a.b2)
@@ -104,114 +183,235 @@ func (a *ARCCache[K, V]) evictFromList(lru, ghost *cacheList[K, V]) {
 	}
 	e := elem.Value.(*entry[K, V])
 	lru.remove(e.key)
-	ghost.addFront(e.key, *new(V))
-	a.emit(cache.Event[K, V]{Type: cache.EventTypeEviction, Key: e.key, Value: e.value})
+	a.expiry.forget(e.key)
+	ghost.addFront(e.key, *new(V), e.weight)
+	a.events.Emit(cache.Event[K, V]{
+		Type:   cache.EventTypeEviction,
+		Key:    e.key,
+		Value:  e.value,
+		Reason: cache.EvictionReasonCapacity,
+	})
 }
 
-func (a *ARCCache[K, V]) adapt(favorRecency bool) {
-	var delta int
-	if favorRecency {
-		if a.b2.len() > 0 {
-			delta = a.b2.len() / max(1, a.b1.len())
+// trimGhosts drops the oldest ghosts until |T1|+|B1| <= c and the whole directory <= 2c, given the sizes of T1 and T2.
+// Trimming after the new entry is in leaves the same ghosts as the paper, which trims before making room for it:
+// the ghost it drops is the oldest one either way.
+func trimGhosts[K comparable, V any](capacity, t1, t2 int64, b1, b2 *cacheList[K, V]) {
+	for t1+b1.size > capacity && b1.len() > 0 {
+		b1.removeOldest()
+	}
+	for t1+t2+b1.size+b2.size > 2*capacity && b1.len()+b2.len() > 0 {
+		if b2.len() > 0 {
+			b2.removeOldest()
 		} else {
-			delta = 1
+			b1.removeOldest()
 		}
-		a.p = min(a.p+delta, a.capacity)
-	} else {
-		if a.b1.len() > 0 {
-			delta = a.b1.len() / max(1, a.b2.len())
-		} else {
-			delta = 1
+	}
+}
+
+// expire drops a resident entry whose deadline has passed.
+// Unlike a replaced entry it leaves no ghost behind: its expiry says nothing about the workload.
+func (a *ARCCache[K, V]) expire(key K) {
+	for _, l := range []*cacheList[K, V]{a.t1, a.t2} {
+		if elem, ok := l.m[key]; ok {
+			l.remove(key)
+			a.expiry.forget(key)
+			a.events.Emit(cache.Event[K, V]{
+				Type:   cache.EventTypeEviction,
+				Key:    key,
+				Value:  elem.Value.(*entry[K, V]).value,
+				Reason: cache.EvictionReasonExpired,
+			})
+			return
 		}
-		a.p = max(a.p-delta, 0)
+	}
+}
+
+// purgeExpired is called by the background cleaner
+func (a *ARCCache[K, V]) purgeExpired() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, key := range a.expiry.popExpired() {
+		a.expire(key)
+	}
+}
+
+// adapt moves the target size of T1 after a ghost hit on an entry of the given weight.
+// The step is the ratio of the other ghost list to the one hit, but at least 1 as in the paper:
+// the integer ratio would be 0 whenever the list hit is the larger one.
+func (a *ARCCache[K, V]) adapt(favorRecency bool, weight int64) {
+	if favorRecency {
+		delta := max(a.b2.size/max(a.b1.size, 1), 1)
+		a.p = min(a.p+delta*weight, int64(a.capacity))
+	} else {
+		delta := max(a.b1.size/max(a.b2.size, 1), 1)
+		a.p = max(a.p-delta*weight, 0)
 	}
 }
 
 func (a *ARCCache[K, V]) Delete(key K) error {
-	for _, l := range []*cacheList[K, V]{a.t1, a.t2, a.b1, a.b2} {
-		if _, ok := l.m[key]; ok {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.delete(key)
+}
+
+// delete removes a resident entry; ghosts are not entries, so deleting a remembered key reports it as not found
+// and leaves its history alone
+func (a *ARCCache[K, V]) delete(key K) error {
+	for _, l := range []*cacheList[K, V]{a.t1, a.t2} {
+		if elem, ok := l.m[key]; ok {
 			l.remove(key)
+			a.expiry.forget(key)
+			a.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: elem.Value.(*entry[K, V]).value})
 			return nil
 		}
 	}
 	return common.ErrKeyNotFound
 }
 
+func (a *ARCCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return getMany(keys, a.get)
+}
+
+// SetMany stores the entries under a single lock acquisition.
+// Unlike the other strategies it replaces victims one entry at a time,
+// since every ghost hit moves the adaptive target that picks the next victim.
+func (a *ARCCache[K, V]) SetMany(entries map[K]V) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var errs []error
+	for key, value := range entries {
+		if err := a.set(key, value); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		a.expiry.trackDefault(key)
+	}
+	return errors.Join(errs...)
+}
+
+func (a *ARCCache[K, V]) DeleteMany(keys []K) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return deleteMany(keys, a.delete)
+}
+
 func (a *ARCCache[K, V]) Clear() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	a.t1 = newCacheList[K, V](false)
 	a.t2 = newCacheList[K, V](false)
 	a.b1 = newCacheList[K, V](true)
 	a.b2 = newCacheList[K, V](true)
 	a.p = 0
+	a.expiry.reset()
+	a.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
 }
 
 func (a *ARCCache[K, V]) Range(fn func(K, V) bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
 	for elem := a.t1.l.Front(); elem != nil; elem = elem.Next() {
 		e := elem.Value.(*entry[K, V])
+		if a.expiry.expired(e.key) {
+			continue
+		}
 		if !fn(e.key, e.value) {
 			return
 		}
 	}
 	for elem := a.t2.l.Front(); elem != nil; elem = elem.Next() {
 		e := elem.Value.(*entry[K, V])
+		if a.expiry.expired(e.key) {
+			continue
+		}
 		if !fn(e.key, e.value) {
 			return
 		}
 	}
 }
 
-func (a *ARCCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) {
-	a.eventCallbacks = append(a.eventCallbacks, callback)
-}
-
-func (a *ARCCache[K, V]) emit(event cache.Event[K, V]) {
-	for _, cb := range a.eventCallbacks {
-		cb(event)
-	}
-}
-
 func newCacheList[K comparable, V any](isGhost bool) *cacheList[K, V] {
 	return &cacheList[K, V]{l: list.New(), m: make(map[K]*list.Element), isGhost: isGhost}
 }
 
-func (cl *cacheList[K, V]) addFront(key K, value V) *list.Element {
+func (cl *cacheList[K, V]) addFront(key K, value V, weight int64) *list.Element {
 	var val any
 	if cl.isGhost {
-		val = &ghostEntry[K]{key}
+		val = &ghostEntry[K]{key, weight}
 	} else {
-		val = &entry[K, V]{key, value}
+		val = &entry[K, V]{key, value, weight}
 	}
 	elem := cl.l.PushFront(val)
 	cl.m[key] = elem
+	cl.size += weight
 	return elem
 }
 
 func (cl *cacheList[K, V]) remove(key K) {
 	if elem, ok := cl.m[key]; ok {
-		cl.l.Remove(elem)
-		delete(cl.m, key)
+		cl.unlink(elem)
 	}
 }
 
 func (cl *cacheList[K, V]) removeOldest() {
-	elem := cl.l.Back()
-	if elem == nil {
-		return
+	if elem := cl.l.Back(); elem != nil {
+		cl.unlink(elem)
 	}
+}
+
+func (cl *cacheList[K, V]) unlink(elem *list.Element) {
 	cl.l.Remove(elem)
 	switch v := elem.Value.(type) {
 	case *entry[K, V]:
 		delete(cl.m, v.key)
+		cl.size -= v.weight
 	case *ghostEntry[K]:
 		delete(cl.m, v.key)
+		cl.size -= v.weight
 	}
 }
 
-func (cl *cacheList[K, V]) moveToFront(key K, value V) {
+// update overwrites the value of a resident entry in place
+func (cl *cacheList[K, V]) update(key K, value V, weight int64) {
+	e := cl.m[key].Value.(*entry[K, V])
+	cl.size += weight - e.weight
+	e.value, e.weight = value, weight
+}
+
+func (cl *cacheList[K, V]) moveToFront(key K, value V, weight int64) {
 	cl.remove(key)
-	cl.addFront(key, value)
+	cl.addFront(key, value, weight)
 }
 
 func (cl *cacheList[K, V]) len() int {
 	return cl.l.Len()
 }
+
+func (a *ARCCache[K, V]) Weight() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.t1.size + a.t2.size
+}
+
+// State returns the current target size of T1 and the size of every list
+func (a *ARCCache[K, V]) State() ARCState {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return ARCState{P: a.p, T1: a.t1.size, T2: a.t2.size, B1: a.b1.size, B2: a.b2.size}
+}
+
+// Stop terminates the background cleaner started by WithExpiration
+func (a *ARCCache[K, V]) Stop() {
+	a.expiry.stop()
+}
+
+func (a *ARCCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return a.events.Subscribe(callback)
+}
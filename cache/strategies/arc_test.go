@@ -2,8 +2,11 @@ package strategies_test
 
 import (
 	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/kimvlry/caching/cache/strategies/common"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"math/rand"
+	"slices"
 	"testing"
 )
 
@@ -43,3 +46,140 @@ func TestARCCache(t *testing.T) {
 		assert.Equal(t, 2, val)
 	}
 }
+
+// referenceArc transcribes the pseudocode of Figure 4 in "ARC: A Self-Tuning, Low Overhead Replacement Cache"
+// (Megiddo and Modha, FAST 2003) as literally as possible. Lists are slices with their LRU end first.
+type referenceArc struct {
+	c, p           int
+	t1, t2, b1, b2 []int
+}
+
+func (r *referenceArc) request(x int) (hit bool) {
+	switch {
+	case slices.Contains(r.t1, x):
+		// Case I
+		r.t1 = remove(r.t1, x)
+		r.t2 = append(r.t2, x)
+		return true
+	case slices.Contains(r.t2, x):
+		r.t2 = append(remove(r.t2, x), x)
+		return true
+	case slices.Contains(r.b1, x):
+		// Case II
+		delta := 1
+		if len(r.b1) < len(r.b2) {
+			delta = len(r.b2) / len(r.b1)
+		}
+		r.p = min(r.p+delta, r.c)
+		r.replace(false)
+		r.b1 = remove(r.b1, x)
+		r.t2 = append(r.t2, x)
+	case slices.Contains(r.b2, x):
+		// Case III
+		delta := 1
+		if len(r.b2) < len(r.b1) {
+			delta = len(r.b1) / len(r.b2)
+		}
+		r.p = max(r.p-delta, 0)
+		r.replace(true)
+		r.b2 = remove(r.b2, x)
+		r.t2 = append(r.t2, x)
+	default:
+		// Case IV
+		if len(r.t1)+len(r.b1) == r.c {
+			if len(r.t1) < r.c {
+				r.b1 = r.b1[1:]
+				r.replace(false)
+			} else {
+				r.t1 = r.t1[1:]
+			}
+		} else if total := len(r.t1) + len(r.t2) + len(r.b1) + len(r.b2); total >= r.c {
+			if total == 2*r.c {
+				r.b2 = r.b2[1:]
+			}
+			r.replace(false)
+		}
+		r.t1 = append(r.t1, x)
+	}
+	return false
+}
+
+func (r *referenceArc) replace(inB2 bool) {
+	if len(r.t1) > 0 && (len(r.t1) > r.p || (inB2 && len(r.t1) == r.p)) {
+		r.b1 = append(r.b1, r.t1[0])
+		r.t1 = r.t1[1:]
+	} else {
+		r.b2 = append(r.b2, r.t2[0])
+		r.t2 = r.t2[1:]
+	}
+}
+
+func remove(list []int, x int) []int {
+	return slices.DeleteFunc(list, func(y int) bool { return y == x })
+}
+
+// TestARCCacheReferenceTrace tests that ARCCache makes the same decisions as the published algorithm:
+// the same hits, the same target p and the same list sizes after every request of a trace mixing
+// a skewed workload, scans and loops
+func TestARCCacheReferenceTrace(t *testing.T) {
+	const capacity = 64
+	c := strategies.NewArcCache[int, int](capacity)()
+	arc := c.(*strategies.ARCCache[int, int])
+	reference := &referenceArc{c: capacity}
+
+	rng := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(rng, 1.2, 1, 500)
+	hits := 0
+	for i := 0; i < 50_000; i++ {
+		var key int
+		switch phase := i / 5000 % 3; phase {
+		case 0:
+			key = int(zipf.Uint64())
+		case 1:
+			key = 1000 + i%5000 // scan
+		default:
+			key = 10_000 + i%(capacity+8) // loop slightly larger than the cache
+		}
+
+		_, err := c.Get(key)
+		if err != nil {
+			require.NoError(t, c.Set(key, key))
+		} else {
+			hits++
+		}
+		require.Equal(t, reference.request(key), err == nil, "request %d for key %d", i, key)
+
+		want := strategies.ARCState{
+			P:  int64(reference.p),
+			T1: int64(len(reference.t1)),
+			T2: int64(len(reference.t2)),
+			B1: int64(len(reference.b1)),
+			B2: int64(len(reference.b2)),
+		}
+		state := arc.State()
+		require.Equal(t, want, state, "request %d for key %d", i, key)
+		require.LessOrEqual(t, state.T1+state.B1, int64(capacity))
+		require.LessOrEqual(t, state.T1+state.T2+state.B1+state.B2, int64(2*capacity))
+	}
+	assert.Greater(t, hits, 0)
+}
+
+// TestARCCacheDeleteGhost tests that deleting a key only remembered by a ghost list reports it missing
+// and keeps its history
+func TestARCCacheDeleteGhost(t *testing.T) {
+	c := strategies.NewArcCache[string, int](2)()
+	arc := c.(*strategies.ARCCache[string, int])
+	require.NoError(t, c.Set("a", 1))
+	_, err := c.Get("a")
+	require.NoError(t, err)
+	require.NoError(t, c.Set("b", 2))
+	require.NoError(t, c.Set("c", 3))
+	assert.Equal(t, strategies.ARCState{T1: 1, T2: 1, B1: 1}, arc.State())
+
+	assert.ErrorIs(t, c.Delete("b"), common.ErrKeyNotFound)
+	assert.Equal(t, strategies.ARCState{T1: 1, T2: 1, B1: 1}, arc.State())
+
+	// The ghost hit still counts: b comes back as a frequent entry and T1 gets a larger target
+	require.NoError(t, c.Set("b", 2))
+	assert.Equal(t, strategies.ARCState{P: 1, T1: 1, T2: 1, B2: 1}, arc.State())
+}
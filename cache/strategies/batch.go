@@ -0,0 +1,67 @@
+package strategies
+
+import "errors"
+
+// Helpers shared by the cache.BatchCache implementations of the strategies.
+// They are called with the strategy's lock held and drive its unlocked single-key methods.
+
+func getMany[K comparable, V any](keys []K, get func(K) (V, error)) (map[K]V, []K) {
+	found := make(map[K]V, len(keys))
+	var missing []K
+	for _, key := range keys {
+		if value, err := get(key); err == nil {
+			found[key] = value
+		} else {
+			missing = append(missing, key)
+		}
+	}
+	return found, missing
+}
+
+// weighMany admits every entry, returning the weights of the admitted ones and the errors of the others
+func weighMany[K comparable, V any](entries map[K]V, w weigher[K, V], capacity int) (map[K]int64, error) {
+	weights := make(map[K]int64, len(entries))
+	var errs []error
+	for key, value := range entries {
+		if weight, err := w.admit(key, value, capacity); err != nil {
+			errs = append(errs, err)
+		} else {
+			weights[key] = weight
+		}
+	}
+	return weights, errors.Join(errs...)
+}
+
+// freshKeys applies update to every admitted entry and returns the keys update reported as absent
+func freshKeys[K comparable, V any](entries map[K]V, weights map[K]int64, update func(K, V, int64) bool) []K {
+	fresh := make([]K, 0, len(weights))
+	for key, weight := range weights {
+		if !update(key, entries[key], weight) {
+			fresh = append(fresh, key)
+		}
+	}
+	return fresh
+}
+
+// fitting drops the keys that would not fit into capacity together even in an empty cache
+// and returns the total weight of the others
+func fitting[K comparable](keys []K, weights map[K]int64, capacity int) ([]K, int64) {
+	total := int64(0)
+	for i := len(keys) - 1; i >= 0; i-- {
+		if total+weights[keys[i]] > int64(capacity) {
+			return keys[i+1:], total
+		}
+		total += weights[keys[i]]
+	}
+	return keys, total
+}
+
+func deleteMany[K comparable](keys []K, del func(K) error) int {
+	deleted := 0
+	for _, key := range keys {
+		if del(key) == nil {
+			deleted++
+		}
+	}
+	return deleted
+}
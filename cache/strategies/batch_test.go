@@ -0,0 +1,91 @@
+package strategies_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func batchFactories(capacity int) map[string]strategies.CacheFactory[string, int] {
+	return map[string]strategies.CacheFactory[string, int]{
+		"lru":     strategies.NewLruCache[string, int](capacity),
+		"lfu":     strategies.NewLfuCache[string, int](capacity),
+		"fifo":    strategies.NewFifoCache[string, int](capacity),
+		"arc":     strategies.NewArcCache[string, int](capacity),
+		"sieve":   strategies.NewSieveCache[string, int](capacity),
+		"s3fifo":  strategies.NewS3FifoCache[string, int](capacity),
+		"clock":   strategies.NewClockCache[string, int](capacity),
+		"slru":    strategies.NewSlruCache[string, int](capacity, 0.8),
+		"2q":      strategies.NewTwoQueueCache[string, int](capacity),
+		"gdsf":    strategies.NewGdsfCache[string, int](capacity, nil),
+		"car":     strategies.NewCarCache[string, int](capacity),
+		"sampled": strategies.NewSampledCache[string, int](capacity, 5, strategies.OldestAccess[string, int]),
+		"ttl":     strategies.NewTtlCache[string, int](capacity, time.Minute),
+		"ttlwheel": strategies.NewTtlCache[string, int](capacity, time.Minute,
+			strategies.WithTimingWheel[string, int](time.Second),
+		),
+		"sharded": strategies.NewShardedCache[string, int](capacity, 1, nil, func(capacity int) strategies.CacheFactory[string, int] {
+			return strategies.NewLruCache[string, int](capacity)
+		}),
+	}
+}
+
+// TestBatchOperations tests the cache.BatchCache implementation of every strategy
+func TestBatchOperations(t *testing.T) {
+	for name, factory := range batchFactories(4) {
+		t.Run(name, func(t *testing.T) {
+			c, ok := factory().(cache.BatchCache[string, int])
+			require.True(t, ok, "strategy should implement BatchCache")
+
+			require.NoError(t, c.SetMany(map[string]int{"a": 1, "b": 2, "c": 3}))
+
+			found, missing := c.GetMany([]string{"a", "x", "b", "c", "y"})
+			assert.Equal(t, map[string]int{"a": 1, "b": 2, "c": 3}, found)
+			assert.Equal(t, []string{"x", "y"}, missing)
+
+			require.NoError(t, c.SetMany(map[string]int{"a": 10}))
+			val, err := c.Get("a")
+			require.NoError(t, err)
+			assert.Equal(t, 10, val, "SetMany should update present keys")
+
+			assert.Equal(t, 2, c.DeleteMany([]string{"a", "b", "missing"}))
+			_, missing = c.GetMany([]string{"a", "b", "c"})
+			assert.Equal(t, []string{"a", "b"}, missing)
+		})
+	}
+}
+
+// TestBatchEviction tests that SetMany never grows a strategy beyond its capacity
+func TestBatchEviction(t *testing.T) {
+	for name, factory := range batchFactories(3) {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+
+			evictions := 0
+			c.(cache.ObservableCache[string, int]).OnEvent(func(event cache.Event[string, int]) {
+				if event.Type == cache.EventTypeEviction {
+					evictions++
+				}
+			})
+
+			require.NoError(t, c.Set("old", 0))
+			require.NoError(t, cache.SetMany[string, int](c, map[string]int{"a": 1, "b": 2, "c": 3}))
+
+			_, err := c.Get("old")
+			assert.Error(t, err, "old entry should make room for the batch")
+			assert.Equal(t, 1, evictions)
+
+			require.NoError(t, cache.SetMany[string, int](c, map[string]int{"d": 4, "e": 5, "f": 6, "g": 7, "h": 8}))
+			size := 0
+			c.Range(func(string, int) bool {
+				size++
+				return true
+			})
+			assert.Equal(t, 3, size)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package strategies
 
 type entry[K comparable, V any] struct {
-	key   K
-	value V
+	key    K
+	value  V
+	weight int64
 }
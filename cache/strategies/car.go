@@ -0,0 +1,269 @@
+package strategies
+
+import (
+	"container/list"
+	"errors"
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+)
+
+// carCache implements CAR, Clock with Adaptive Replacement (Bansal and Modha).
+// Like ARC it splits entries into T1, used once recently, and T2, used at least twice, remembers recently evicted
+// keys in the ghost lists B1 and B2 and adapts p, the target size of T1, on ghost hits. T1 and T2 are clocks rather
+// than LRU lists, though: a hit only sets the referenced bit of its entry, and the hands do the work at eviction time.
+// The T1 hand moves referenced entries to T2, the T2 hand gives them a second chance.
+type carCache[K comparable, V any] struct {
+	capacity int
+	p        int64
+	weigher  weigher[K, V]
+
+	data     map[K]*list.Element
+	t1, t2   *list.List // *carEntry, the entry under the hand at the front
+	t1Weight int64
+	t2Weight int64
+	b1, b2   *cacheList[K, V] // most recent ghost at the front
+	mu       sync.Locker
+
+	events *cache.EventDispatcher[K, V]
+}
+
+type carEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	weight     int64
+	referenced bool
+	inT2       bool
+}
+
+func newCarCache[K comparable, V any](capacity int, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	capacity = max(capacity, 1)
+	cfg := newConfig(opts)
+	c := &carCache[K, V]{
+		capacity: capacity,
+		weigher:  cfg.weigher,
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
+	}
+	c.reset()
+	return c
+}
+
+func (c *carCache[K, V]) reset() {
+	c.data = make(map[K]*list.Element, c.weigher.sizeHint(c.capacity))
+	c.t1, c.t2 = list.New(), list.New()
+	c.t1Weight, c.t2Weight = 0, 0
+	c.b1, c.b2 = newCacheList[K, V](true), newCacheList[K, V](true)
+	c.p = 0
+}
+
+func (c *carCache[K, V]) Get(key K) (V, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+func (c *carCache[K, V]) get(key K) (V, error) {
+	if elem, exists := c.data[key]; exists {
+		e := elem.Value.(*carEntry[K, V])
+		e.referenced = true
+		c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: e.value})
+		return e.value, nil
+	}
+
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+	var zero V
+	return zero, common.ErrKeyNotFound
+}
+
+func (c *carCache[K, V]) Set(key K, value V) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.set(key, value)
+}
+
+func (c *carCache[K, V]) set(key K, value V) error {
+	weight, err := c.weigher.admit(key, value, c.capacity)
+	if err != nil {
+		return err
+	}
+	if elem, exists := c.data[key]; exists {
+		e := elem.Value.(*carEntry[K, V])
+		*c.clockWeight(e) += weight - e.weight
+		e.value, e.weight = value, weight
+		e.referenced = true
+		c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+		// The new value may weigh more than the old one
+		c.makeRoom(0)
+		return nil
+	}
+
+	inB1, inB2 := c.b1.m[key] != nil, c.b2.m[key] != nil
+	c.makeRoom(weight)
+	e := &carEntry[K, V]{key: key, value: value, weight: weight}
+	switch {
+	case inB1:
+		c.p = min(c.p+max(c.b2.size/max(c.b1.size, 1), 1)*weight, int64(c.capacity))
+		c.b1.remove(key)
+		c.pushT2(e)
+	case inB2:
+		c.p = max(c.p-max(c.b1.size/max(c.b2.size, 1), 1)*weight, 0)
+		c.b2.remove(key)
+		c.pushT2(e)
+	default:
+		c.data[key] = c.t1.PushBack(e)
+		c.t1Weight += weight
+	}
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+	trimGhosts(int64(c.capacity), c.t1Weight, c.t2Weight, c.b1, c.b2)
+	return nil
+}
+
+// pushT2 puts an entry just behind the T2 hand
+func (c *carCache[K, V]) pushT2(e *carEntry[K, V]) {
+	e.inT2 = true
+	c.data[e.key] = c.t2.PushBack(e)
+	c.t2Weight += e.weight
+}
+
+func (c *carCache[K, V]) clockWeight(e *carEntry[K, V]) *int64 {
+	if e.inT2 {
+		return &c.t2Weight
+	}
+	return &c.t1Weight
+}
+
+// makeRoom runs the hands until weight more fits into the capacity
+func (c *carCache[K, V]) makeRoom(weight int64) {
+	for c.t1Weight+c.t2Weight+weight > int64(c.capacity) && len(c.data) > 0 {
+		c.replace()
+	}
+}
+
+// replace evicts one entry: from T1 while it holds at least its target, from T2 otherwise.
+// Referenced entries under the T1 hand move to T2, those under the T2 hand go around once more.
+func (c *carCache[K, V]) replace() {
+	for {
+		if c.t1.Len() > 0 && (c.t1Weight >= max(c.p, 1) || c.t2.Len() == 0) {
+			e := c.t1.Front().Value.(*carEntry[K, V])
+			if !e.referenced {
+				c.evict(c.t1, e, c.b1)
+				return
+			}
+			e.referenced = false
+			c.t1.Remove(c.data[e.key])
+			c.t1Weight -= e.weight
+			c.pushT2(e)
+		} else {
+			elem := c.t2.Front()
+			e := elem.Value.(*carEntry[K, V])
+			if !e.referenced {
+				c.evict(c.t2, e, c.b2)
+				return
+			}
+			e.referenced = false
+			c.t2.MoveToBack(elem)
+		}
+	}
+}
+
+// evict demotes the entry under a hand to the front of its ghost list
+func (c *carCache[K, V]) evict(clock *list.List, e *carEntry[K, V], ghost *cacheList[K, V]) {
+	c.remove(clock, e)
+	ghost.addFront(e.key, *new(V), e.weight)
+	c.events.Emit(cache.Event[K, V]{
+		Type:   cache.EventTypeEviction,
+		Key:    e.key,
+		Value:  e.value,
+		Reason: cache.EvictionReasonCapacity,
+	})
+}
+
+func (c *carCache[K, V]) remove(clock *list.List, e *carEntry[K, V]) {
+	clock.Remove(c.data[e.key])
+	*c.clockWeight(e) -= e.weight
+	delete(c.data, e.key)
+}
+
+func (c *carCache[K, V]) Delete(key K) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.delete(key)
+}
+
+// delete removes a resident entry; like ARC it leaves the ghosts alone
+func (c *carCache[K, V]) delete(key K) error {
+	elem, exists := c.data[key]
+	if !exists {
+		return common.ErrKeyNotFound
+	}
+
+	e := elem.Value.(*carEntry[K, V])
+	if e.inT2 {
+		c.remove(c.t2, e)
+	} else {
+		c.remove(c.t1, e)
+	}
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: e.value})
+	return nil
+}
+
+func (c *carCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return getMany(keys, c.get)
+}
+
+// SetMany stores the entries under a single lock acquisition.
+// Like ARC it replaces victims one entry at a time, since every ghost hit moves the adaptive target.
+func (c *carCache[K, V]) SetMany(entries map[K]V) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for key, value := range entries {
+		if err := c.set(key, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *carCache[K, V]) DeleteMany(keys []K) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return deleteMany(keys, c.delete)
+}
+
+func (c *carCache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reset()
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits T1, then T2, each from the entry under its hand
+func (c *carCache[K, V]) Range(fn func(K, V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, clock := range []*list.List{c.t1, c.t2} {
+		for elem := clock.Front(); elem != nil; elem = elem.Next() {
+			e := elem.Value.(*carEntry[K, V])
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+func (c *carCache[K, V]) Weight() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t1Weight + c.t2Weight
+}
+
+func (c *carCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return c.events.Subscribe(callback)
+}
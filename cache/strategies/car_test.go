@@ -0,0 +1,134 @@
+package strategies_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// referenceCar transcribes the pseudocode of Figure 2 in "CAR: Clock with Adaptive Replacement"
+// (Bansal and Modha, FAST 2004) as literally as possible. Clocks are slices with the page under the hand first,
+// ghost lists are slices with their LRU end first.
+type referenceCar struct {
+	c, p           int
+	t1, t2, b1, b2 []int
+	referenced     map[int]bool
+}
+
+func (r *referenceCar) request(x int) (hit bool) {
+	if slices.Contains(r.t1, x) || slices.Contains(r.t2, x) {
+		r.referenced[x] = true
+		return true
+	}
+
+	inB1, inB2 := slices.Contains(r.b1, x), slices.Contains(r.b2, x)
+	if len(r.t1)+len(r.t2) == r.c {
+		r.replace()
+		if !inB1 && !inB2 && len(r.t1)+len(r.b1) == r.c {
+			r.b1 = r.b1[1:]
+		} else if len(r.t1)+len(r.t2)+len(r.b1)+len(r.b2) == 2*r.c && !inB1 && !inB2 {
+			r.b2 = r.b2[1:]
+		}
+	}
+	r.referenced[x] = false
+	switch {
+	case !inB1 && !inB2:
+		r.t1 = append(r.t1, x)
+	case inB1:
+		r.p = min(r.p+max(1, len(r.b2)/len(r.b1)), r.c)
+		r.b1 = remove(r.b1, x)
+		r.t2 = append(r.t2, x)
+	default:
+		r.p = max(r.p-max(1, len(r.b1)/len(r.b2)), 0)
+		r.b2 = remove(r.b2, x)
+		r.t2 = append(r.t2, x)
+	}
+	return false
+}
+
+func (r *referenceCar) replace() {
+	for {
+		if len(r.t1) >= max(1, r.p) {
+			head := r.t1[0]
+			r.t1 = r.t1[1:]
+			if !r.referenced[head] {
+				r.b1 = append(r.b1, head)
+				delete(r.referenced, head)
+				return
+			}
+			r.referenced[head] = false
+			r.t2 = append(r.t2, head)
+		} else {
+			head := r.t2[0]
+			r.t2 = r.t2[1:]
+			if !r.referenced[head] {
+				r.b2 = append(r.b2, head)
+				delete(r.referenced, head)
+				return
+			}
+			r.referenced[head] = false
+			r.t2 = append(r.t2, head)
+		}
+	}
+}
+
+// TestCarCacheReferenceTrace tests that the CAR cache makes the same decisions as the published algorithm:
+// the same hits and the same entries under each hand after every request of a mixed trace
+func TestCarCacheReferenceTrace(t *testing.T) {
+	const capacity = 64
+	c := strategies.NewCarCache[int, int](capacity)()
+	reference := &referenceCar{c: capacity, referenced: make(map[int]bool)}
+
+	rng := rand.New(rand.NewSource(1))
+	zipf := rand.NewZipf(rng, 1.2, 1, 500)
+	for i := 0; i < 50_000; i++ {
+		var key int
+		switch phase := i / 5000 % 3; phase {
+		case 0:
+			key = int(zipf.Uint64())
+		case 1:
+			key = 1000 + i%5000 // scan
+		default:
+			key = 10_000 + i%(capacity+8) // loop slightly larger than the cache
+		}
+
+		_, err := c.Get(key)
+		if err != nil {
+			require.NoError(t, c.Set(key, key))
+		}
+		require.Equal(t, reference.request(key), err == nil, "request %d for key %d", i, key)
+
+		var keys []int
+		c.Range(func(key, _ int) bool {
+			keys = append(keys, key)
+			return true
+		})
+		require.Equal(t, append(slices.Clone(reference.t1), reference.t2...), keys, "request %d for key %d", i, key)
+	}
+}
+
+// TestCarCache tests that hits only set a bit, and that the hand moves referenced entries from T1 to T2
+func TestCarCache(t *testing.T) {
+	c := strategies.NewCarCache[string, int](3)()
+	events := recordEvents(t, c)
+	for i, key := range []string{"a", "b", "c"} {
+		require.NoError(t, c.Set(key, i))
+	}
+	_, err := c.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, keysOf(c))
+
+	// a was referenced, so the T1 hand moves it to T2 and evicts b instead
+	require.NoError(t, c.Set("d", 3))
+	assert.Equal(t, []string{"b"}, evictedKeys(*events))
+	assert.Equal(t, []string{"c", "d", "a"}, keysOf(c))
+
+	// b is still remembered in B1, so it comes back into T2 once the T1 hand has evicted c
+	require.NoError(t, c.Set("b", 1))
+	assert.Equal(t, []string{"b", "c"}, evictedKeys(*events))
+	assert.Equal(t, []string{"d", "a", "b"}, keysOf(c))
+}
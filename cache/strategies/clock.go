@@ -0,0 +1,229 @@
+package strategies
+
+import (
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+)
+
+// clockCache implements the CLOCK (second chance) policy on a fixed-size array of slots.
+// A hit only sets the referenced bit of its slot; at eviction time the hand sweeps the slots,
+// clearing referenced bits and evicting the first slot that was not referenced since the last sweep.
+// With a weigher the number of entries is not known up front, so the array grows as entries come in instead.
+type clockCache[K comparable, V any] struct {
+	capacity int
+	weight   int64
+	weigher  weigher[K, V]
+	slots    []clockSlot[K, V]
+	index    map[K]int
+	free     []int // unoccupied slots, the next one to fill last
+	hand     int
+	mu       sync.Locker
+
+	events *cache.EventDispatcher[K, V]
+}
+
+type clockSlot[K comparable, V any] struct {
+	key        K
+	value      V
+	weight     int64
+	referenced bool
+	occupied   bool
+}
+
+func newClockCache[K comparable, V any](capacity int, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	capacity = max(capacity, 1)
+	cfg := newConfig(opts)
+	c := &clockCache[K, V]{
+		capacity: capacity,
+		weigher:  cfg.weigher,
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
+	}
+	c.reset()
+	return c
+}
+
+func (c *clockCache[K, V]) reset() {
+	size := c.weigher.sizeHint(c.capacity)
+	c.slots = make([]clockSlot[K, V], size)
+	c.index = make(map[K]int, size)
+	c.free = make([]int, size)
+	for i := range c.free {
+		c.free[i] = size - 1 - i
+	}
+	c.hand = 0
+	c.weight = 0
+}
+
+func (c *clockCache[K, V]) Get(key K) (V, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+func (c *clockCache[K, V]) get(key K) (V, error) {
+	if i, exists := c.index[key]; exists {
+		slot := &c.slots[i]
+		slot.referenced = true
+		c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: slot.value})
+		return slot.value, nil
+	}
+
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+	var zero V
+	return zero, common.ErrKeyNotFound
+}
+
+func (c *clockCache[K, V]) Set(key K, value V) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	weight, err := c.weigher.admit(key, value, c.capacity)
+	if err != nil {
+		return err
+	}
+	if c.update(key, value, weight) {
+		// The new value may weigh more than the old one
+		c.evict(0)
+		return nil
+	}
+	c.evict(weight)
+	c.insert(key, value, weight)
+	return nil
+}
+
+// update overwrites the value of a present key and gives it a second chance
+func (c *clockCache[K, V]) update(key K, value V, weight int64) bool {
+	i, exists := c.index[key]
+	if !exists {
+		return false
+	}
+	slot := &c.slots[i]
+	c.weight += weight - slot.weight
+	slot.value, slot.weight = value, weight
+	slot.referenced = true
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+	return true
+}
+
+func (c *clockCache[K, V]) insert(key K, value V, weight int64) {
+	if len(c.free) == 0 {
+		c.free = append(c.free, len(c.slots))
+		c.slots = append(c.slots, clockSlot[K, V]{})
+	}
+	i := c.free[len(c.free)-1]
+	c.free = c.free[:len(c.free)-1]
+	c.slots[i] = clockSlot[K, V]{key: key, value: value, weight: weight, occupied: true}
+	c.index[key] = i
+	c.weight += weight
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+}
+
+// evict removes entries that were not referenced since the hand last passed them until weight more fits into
+// the capacity
+func (c *clockCache[K, V]) evict(weight int64) {
+	for c.weight+weight > int64(c.capacity) && len(c.index) > 0 {
+		for {
+			slot := &c.slots[c.hand]
+			if slot.occupied && !slot.referenced {
+				break
+			}
+			slot.referenced = false
+			c.hand = (c.hand + 1) % len(c.slots)
+		}
+
+		evicted := c.slots[c.hand]
+		c.remove(c.hand)
+		c.hand = (c.hand + 1) % len(c.slots)
+		c.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    evicted.key,
+			Value:  evicted.value,
+			Reason: cache.EvictionReasonCapacity,
+		})
+	}
+}
+
+// remove frees slot i without emitting an event
+func (c *clockCache[K, V]) remove(i int) {
+	delete(c.index, c.slots[i].key)
+	c.weight -= c.slots[i].weight
+	c.slots[i] = clockSlot[K, V]{}
+	c.free = append(c.free, i)
+}
+
+func (c *clockCache[K, V]) Delete(key K) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.delete(key)
+}
+
+func (c *clockCache[K, V]) delete(key K) error {
+	i, exists := c.index[key]
+	if !exists {
+		return common.ErrKeyNotFound
+	}
+	value := c.slots[i].value
+	c.remove(i)
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: value})
+	return nil
+}
+
+func (c *clockCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return getMany(keys, c.get)
+}
+
+// SetMany updates present keys, then makes room for all new keys in a single eviction pass.
+// If the new keys outweigh the capacity, only as many of them as fit are stored.
+func (c *clockCache[K, V]) SetMany(entries map[K]V) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	weights, err := weighMany(entries, c.weigher, c.capacity)
+	fresh, weight := fitting(freshKeys(entries, weights, c.update), weights, c.capacity)
+	c.evict(weight)
+	for _, key := range fresh {
+		c.insert(key, entries[key], weights[key])
+	}
+	return err
+}
+
+func (c *clockCache[K, V]) DeleteMany(keys []K) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return deleteMany(keys, c.delete)
+}
+
+func (c *clockCache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reset()
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits entries in slot order
+func (c *clockCache[K, V]) Range(fn func(K, V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.slots {
+		slot := &c.slots[i]
+		if slot.occupied && !fn(slot.key, slot.value) {
+			break
+		}
+	}
+}
+
+func (c *clockCache[K, V]) Weight() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.weight
+}
+
+func (c *clockCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return c.events.Subscribe(callback)
+}
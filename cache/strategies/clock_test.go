@@ -0,0 +1,152 @@
+package strategies_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestClockCache tests that referenced entries get a second chance
+func TestClockCache(t *testing.T) {
+	c := strategies.NewClockCache[string, int](3)()
+
+	require.NoError(t, c.Set("a", 1))
+	require.NoError(t, c.Set("b", 2))
+	require.NoError(t, c.Set("c", 3))
+	_, err := c.Get("a")
+	require.NoError(t, err)
+
+	// The hand clears the referenced bit of "a" and evicts "b" instead; "d" takes its slot
+	require.NoError(t, c.Set("d", 4))
+	assert.Equal(t, []string{"a", "d", "c"}, keysOf(c))
+
+	// "a" used its second chance, the hand moved on past "d" to "c"
+	require.NoError(t, c.Set("e", 5))
+	assert.Equal(t, []string{"a", "d", "e"}, keysOf(c))
+	require.NoError(t, c.Set("f", 6))
+	assert.Equal(t, []string{"f", "d", "e"}, keysOf(c))
+}
+
+// TestClockProCache tests basic operations and that a key reused during its test period comes back
+func TestClockProCache(t *testing.T) {
+	c := strategies.NewClockProCache[string, int](3)()
+
+	var evicted []string
+	c.(cache.ObservableCache[string, int]).OnEvent(func(event cache.Event[string, int]) {
+		if event.Type == cache.EventTypeEviction {
+			evicted = append(evicted, event.Key)
+		}
+	})
+
+	for i, key := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, c.Set(key, i))
+	}
+	require.Len(t, evicted, 1)
+	_, err := c.Get(evicted[0])
+	assert.Error(t, err, "a test page is not resident")
+	assert.Error(t, c.Delete(evicted[0]))
+
+	require.NoError(t, c.Set(evicted[0], 10))
+	val, err := c.Get(evicted[0])
+	require.NoError(t, err)
+	assert.Equal(t, 10, val)
+	assert.Len(t, keysOf(c), 3)
+}
+
+// TestClockProCacheScanResistance tests that a scan of one-hit wonders does not flush frequently used entries
+func TestClockProCacheScanResistance(t *testing.T) {
+	c := strategies.NewClockProCache[string, int](100)()
+	for round := 0; round < 5; round++ {
+		for i := 0; i < 50; i++ {
+			key := fmt.Sprintf("hot-%d", i)
+			if _, err := c.Get(key); err != nil {
+				_ = c.Set(key, i)
+			}
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		_ = c.Set(fmt.Sprintf("scan-%d", i), i)
+	}
+
+	survivors := 0
+	for _, key := range keysOf(c) {
+		if strings.HasPrefix(key, "hot-") {
+			survivors++
+		}
+	}
+	assert.GreaterOrEqual(t, survivors, 45)
+}
+
+// TestClockPoliciesHitRate tests that the clocks keep up with LRU on a skewed workload
+func TestClockPoliciesHitRate(t *testing.T) {
+	const requests = 50_000
+
+	lru := hits(strategies.NewLruCache[int, int](500), requests)
+	assert.Greater(t, hits(strategies.NewClockCache[int, int](500), requests), lru*95/100)
+	assert.Greater(t, hits(strategies.NewClockProCache[int, int](500), requests), lru*95/100)
+}
+
+// TestClockProCacheRandomTrace tests that CLOCK-Pro never outgrows its capacity under a mixed workload
+func TestClockProCacheRandomTrace(t *testing.T) {
+	c := strategies.NewClockProCache[int, int](16)()
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 20_000; i++ {
+		key := rng.Intn(64)
+		switch rng.Intn(10) {
+		case 0:
+			_ = c.Delete(key)
+		case 1, 2, 3, 4:
+			_ = c.Set(key, i)
+		default:
+			_, _ = c.Get(key)
+		}
+
+		size := 0
+		c.Range(func(int, int) bool {
+			size++
+			return true
+		})
+		require.LessOrEqual(t, size, 16)
+	}
+}
+
+// TestClockProCacheSinglePage tests that the hands make progress when every one of them sits on the same hot page
+func TestClockProCacheSinglePage(t *testing.T) {
+	c := strategies.NewClockProCache[int, int](1)()
+	require.NoError(t, c.Set(0, 0))
+	require.NoError(t, c.Set(0, 0))
+	require.NoError(t, c.Set(2, 2))
+
+	val, err := c.Get(2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, val)
+	_, err = c.Get(0)
+	assert.Error(t, err)
+}
+
+// TestClockProCacheWeightedHotPages tests that hot pages filling a weighted capacity get demoted and evicted
+func TestClockProCacheWeightedHotPages(t *testing.T) {
+	for _, capacity := range []int{2, 3, 5, 8} {
+		t.Run(fmt.Sprint(capacity), func(t *testing.T) {
+			c := strategies.NewClockProCache[int, int](capacity,
+				strategies.WithWeigher(func(_ int, value int) int64 { return int64(value) }),
+			)()
+			rng := rand.New(rand.NewSource(int64(capacity)))
+			for i := 0; i < 5000; i++ {
+				key := rng.Intn(6)
+				if rng.Intn(3) == 0 {
+					_, _ = c.Get(key)
+					continue
+				}
+				require.NoError(t, c.Set(key, 1+rng.Intn(capacity)))
+				require.LessOrEqual(t, c.(strategies.WeightedCache[int, int]).Weight(), int64(capacity))
+			}
+		})
+	}
+}
@@ -0,0 +1,365 @@
+package strategies
+
+import (
+	"errors"
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+)
+
+type clockProPage uint8
+
+const (
+	pageFree clockProPage = iota
+	pageHot
+	pageCold
+	pageTest // non-resident cold page, only its key is kept
+)
+
+const noPage = -1
+
+// clockProCache implements CLOCK-Pro.
+// Resident hot and cold pages and non-resident test pages share one clock, a ring linked through indices of a fixed
+// array of 2*capacity pages. The cold hand evicts unreferenced cold pages, keeping them as test pages for a while,
+// and promotes referenced ones to hot; the hot hand demotes hot pages that were not referenced since its last pass;
+// the test hand forgets old test pages. A miss on a test page means the cold target was too small: it grows,
+// and the page comes back hot. Pages seen once, like a scan, stay cold and are the first to go.
+// With a weigher the number of pages is not known up front, so the array grows as pages come in instead.
+type clockProCache[K comparable, V any] struct {
+	capacity   int
+	coldTarget int64 // adaptive share of the capacity for resident cold pages
+	weigher    weigher[K, V]
+
+	pages []clockProNode[K, V]
+	index map[K]int
+	free  []int
+
+	handHot, handCold, handTest       int
+	hotWeight, coldWeight, testWeight int64
+
+	mu     sync.Locker
+	events *cache.EventDispatcher[K, V]
+}
+
+type clockProNode[K comparable, V any] struct {
+	key        K
+	value      V
+	weight     int64
+	page       clockProPage
+	referenced bool
+	prev, next int
+}
+
+func newClockProCache[K comparable, V any](capacity int, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	capacity = max(capacity, 1)
+	cfg := newConfig(opts)
+	c := &clockProCache[K, V]{
+		capacity: capacity,
+		weigher:  cfg.weigher,
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
+	}
+	c.reset()
+	return c
+}
+
+func (c *clockProCache[K, V]) reset() {
+	c.coldTarget = int64(max(c.capacity/10, 1))
+	size := 2 * c.weigher.sizeHint(c.capacity)
+	c.pages = make([]clockProNode[K, V], size)
+	c.index = make(map[K]int, size)
+	c.free = make([]int, len(c.pages))
+	for i := range c.free {
+		c.free[i] = len(c.pages) - 1 - i
+	}
+	c.handHot, c.handCold, c.handTest = noPage, noPage, noPage
+	c.hotWeight, c.coldWeight, c.testWeight = 0, 0, 0
+}
+
+func (c *clockProCache[K, V]) Get(key K) (V, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+func (c *clockProCache[K, V]) get(key K) (V, error) {
+	if i, exists := c.index[key]; exists && c.pages[i].page != pageTest {
+		node := &c.pages[i]
+		node.referenced = true
+		c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: node.value})
+		return node.value, nil
+	}
+
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+	var zero V
+	return zero, common.ErrKeyNotFound
+}
+
+func (c *clockProCache[K, V]) Set(key K, value V) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.set(key, value)
+}
+
+func (c *clockProCache[K, V]) set(key K, value V) error {
+	weight, err := c.weigher.admit(key, value, c.capacity)
+	if err != nil {
+		return err
+	}
+	i, exists := c.index[key]
+	switch {
+	case !exists:
+		c.add(key, value, weight, pageCold)
+		c.coldWeight += weight
+	case c.pages[i].page != pageTest:
+		node := &c.pages[i]
+		*c.pageWeight(node.page) += weight - node.weight
+		node.value, node.weight = value, weight
+		node.referenced = true
+		c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+		// The new value may weigh more than the old one
+		c.makeRoom(0)
+		return nil
+	default:
+		// Reused during its test period: cold pages deserve more room, and this one comes back hot
+		c.coldTarget = min(c.coldTarget+c.pages[i].weight, int64(c.capacity))
+		c.testWeight -= c.pages[i].weight
+		c.unlink(i)
+		c.add(key, value, weight, pageHot)
+		c.hotWeight += weight
+	}
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+	return nil
+}
+
+// add makes room for a resident page and links it in front of the hot hand
+func (c *clockProCache[K, V]) add(key K, value V, weight int64, page clockProPage) {
+	c.makeRoom(weight)
+
+	if len(c.free) == 0 {
+		c.free = append(c.free, len(c.pages))
+		c.pages = append(c.pages, clockProNode[K, V]{})
+	}
+	i := c.free[len(c.free)-1]
+	c.free = c.free[:len(c.free)-1]
+	c.pages[i] = clockProNode[K, V]{key: key, value: value, weight: weight, page: page}
+	c.index[key] = i
+	c.link(i, c.handHot)
+
+	if c.handCold == noPage {
+		c.handHot, c.handCold, c.handTest = i, i, i
+	}
+	if c.handCold == c.handHot {
+		c.handCold = c.pages[c.handCold].prev
+	}
+}
+
+// makeRoom runs the cold hand until weight more fits into the capacity.
+// Every hand step below either moves a hand or changes a page, and none of them calls another hand, so the loops here
+// are the only place hands are driven from.
+func (c *clockProCache[K, V]) makeRoom(weight int64) {
+	for c.hotWeight+c.coldWeight+weight > int64(c.capacity) && c.handCold != noPage {
+		if c.coldWeight == 0 {
+			// Only hot pages are left: demote one for the cold hand to evict
+			c.demoteHot()
+			continue
+		}
+		c.runHandCold()
+		c.balance()
+	}
+}
+
+// balance runs the hot hand until hot pages fit next to the cold target, and the test hand until test pages fit
+// into the capacity
+func (c *clockProCache[K, V]) balance() {
+	for int64(c.capacity)-c.coldTarget < c.hotWeight {
+		c.demoteHot()
+	}
+	for c.testWeight > int64(c.capacity) {
+		c.runHandTest()
+	}
+}
+
+// demoteHot runs the hot hand until it demotes a page. There must be a hot page: the hand clears the referenced bits
+// it passes, so it demotes one within two turns.
+func (c *clockProCache[K, V]) demoteHot() {
+	for !c.runHandHot() {
+	}
+}
+
+func (c *clockProCache[K, V]) pageWeight(page clockProPage) *int64 {
+	switch page {
+	case pageHot:
+		return &c.hotWeight
+	case pageCold:
+		return &c.coldWeight
+	default:
+		return &c.testWeight
+	}
+}
+
+// runHandCold evicts the cold page under the hand unless it was referenced, in which case it becomes hot
+func (c *clockProCache[K, V]) runHandCold() {
+	node := &c.pages[c.handCold]
+	if node.page == pageCold {
+		if node.referenced {
+			node.page = pageHot
+			node.referenced = false
+			c.coldWeight -= node.weight
+			c.hotWeight += node.weight
+		} else {
+			c.events.Emit(cache.Event[K, V]{
+				Type:   cache.EventTypeEviction,
+				Key:    node.key,
+				Value:  node.value,
+				Reason: cache.EvictionReasonCapacity,
+			})
+			var zero V
+			node.page = pageTest
+			node.value = zero
+			c.coldWeight -= node.weight
+			c.testWeight += node.weight
+		}
+	}
+	c.handCold = c.pages[c.handCold].next
+}
+
+// runHandHot demotes the hot page under the hand to cold unless it was referenced since the last pass,
+// reporting whether it demoted one
+func (c *clockProCache[K, V]) runHandHot() bool {
+	node := &c.pages[c.handHot]
+	demoted := false
+	if node.page == pageHot {
+		if node.referenced {
+			node.referenced = false
+		} else {
+			node.page = pageCold
+			c.hotWeight -= node.weight
+			c.coldWeight += node.weight
+			demoted = true
+		}
+	}
+	c.handHot = c.pages[c.handHot].next
+	return demoted
+}
+
+// runHandTest forgets the test page under the hand; its test period ended without reuse, so cold pages get less room
+func (c *clockProCache[K, V]) runHandTest() {
+	if i := c.handTest; c.pages[i].page == pageTest {
+		c.testWeight -= c.pages[i].weight
+		c.coldTarget = max(c.coldTarget-c.pages[i].weight, 1)
+		c.unlink(i)
+	}
+	if c.handTest != noPage {
+		c.handTest = c.pages[c.handTest].next
+	}
+}
+
+// link inserts page i into the ring just before page at, or starts the ring
+func (c *clockProCache[K, V]) link(i, at int) {
+	if at == noPage {
+		c.pages[i].prev, c.pages[i].next = i, i
+		return
+	}
+	prev := c.pages[at].prev
+	c.pages[i].prev, c.pages[i].next = prev, at
+	c.pages[prev].next = i
+	c.pages[at].prev = i
+}
+
+// unlink takes page i out of the ring and frees it, moving the hands that pointed at it back to its predecessor
+func (c *clockProCache[K, V]) unlink(i int) {
+	prev, next := c.pages[i].prev, c.pages[i].next
+	if next == i {
+		c.handHot, c.handCold, c.handTest = noPage, noPage, noPage
+	} else {
+		for _, hand := range []*int{&c.handHot, &c.handCold, &c.handTest} {
+			if *hand == i {
+				*hand = prev
+			}
+		}
+		c.pages[prev].next = next
+		c.pages[next].prev = prev
+	}
+
+	delete(c.index, c.pages[i].key)
+	c.pages[i] = clockProNode[K, V]{}
+	c.free = append(c.free, i)
+}
+
+func (c *clockProCache[K, V]) Delete(key K) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.delete(key)
+}
+
+func (c *clockProCache[K, V]) delete(key K) error {
+	i, exists := c.index[key]
+	if !exists || c.pages[i].page == pageTest {
+		return common.ErrKeyNotFound
+	}
+	node := c.pages[i]
+	*c.pageWeight(node.page) -= node.weight
+	c.unlink(i)
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: node.value})
+	return nil
+}
+
+func (c *clockProCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return getMany(keys, c.get)
+}
+
+// SetMany stores the entries under a single lock acquisition.
+// Like ARC it adds entries one at a time, since every insertion moves the hands that pick the next victim.
+func (c *clockProCache[K, V]) SetMany(entries map[K]V) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var errs []error
+	for key, value := range entries {
+		if err := c.set(key, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (c *clockProCache[K, V]) DeleteMany(keys []K) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return deleteMany(keys, c.delete)
+}
+
+func (c *clockProCache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.reset()
+	c.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits resident pages in slot order
+func (c *clockProCache[K, V]) Range(fn func(K, V) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.pages {
+		node := &c.pages[i]
+		if (node.page == pageHot || node.page == pageCold) && !fn(node.key, node.value) {
+			break
+		}
+	}
+}
+
+func (c *clockProCache[K, V]) Weight() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hotWeight + c.coldWeight
+}
+
+func (c *clockProCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return c.events.Subscribe(callback)
+}
@@ -1,9 +1,24 @@
 package common
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 // Common errors
 var (
 	ErrKeyNotFound = errors.New("key not found")
 	ErrCacheFull   = errors.New("cache is full")
 )
+
+// EntryTooLargeError is returned when an entry weighs more than the whole capacity of a weighted cache,
+// so storing it would leave no room even if every other entry were evicted
+type EntryTooLargeError struct {
+	Key      any
+	Weight   int64
+	Capacity int64
+}
+
+func (e *EntryTooLargeError) Error() string {
+	return fmt.Sprintf("entry %v weighs %d, more than the cache capacity of %d", e.Key, e.Weight, e.Capacity)
+}
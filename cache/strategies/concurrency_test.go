@@ -0,0 +1,113 @@
+package strategies_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+)
+
+func concurrentFactories() map[string]strategies.CacheFactory[int, int] {
+	return map[string]strategies.CacheFactory[int, int]{
+		"lru":      strategies.NewLruCache[int, int](64, strategies.WithConcurrency[int, int]()),
+		"lfu":      strategies.NewLfuCache[int, int](64, strategies.WithConcurrency[int, int]()),
+		"fifo":     strategies.NewFifoCache[int, int](64, strategies.WithConcurrency[int, int]()),
+		"arc":      strategies.NewArcCache[int, int](64, strategies.WithConcurrency[int, int]()),
+		"tinylfu":  strategies.NewTinyLfuCache[int, int](64, strategies.WithConcurrency[int, int]()),
+		"sieve":    strategies.NewSieveCache[int, int](64, strategies.WithConcurrency[int, int]()),
+		"s3fifo":   strategies.NewS3FifoCache[int, int](64, strategies.WithConcurrency[int, int]()),
+		"clock":    strategies.NewClockCache[int, int](64, strategies.WithConcurrency[int, int]()),
+		"clockpro": strategies.NewClockProCache[int, int](64, strategies.WithConcurrency[int, int]()),
+		"slru":     strategies.NewSlruCache[int, int](64, 0.8, strategies.WithConcurrency[int, int]()),
+		"2q":       strategies.NewTwoQueueCache[int, int](64, strategies.WithConcurrency[int, int]()),
+		"lirs":     strategies.NewLirsCache[int, int](64, 0.1, strategies.WithConcurrency[int, int]()),
+		"gdsf":     strategies.NewGdsfCache[int, int](64, nil, strategies.WithConcurrency[int, int]()),
+		"car":      strategies.NewCarCache[int, int](64, strategies.WithConcurrency[int, int]()),
+		"sampled":  strategies.NewSampledCache[int, int](64, 5, strategies.OldestAccess[int, int], strategies.WithConcurrency[int, int]()),
+		"ttl":      strategies.NewTtlCache[int, int](64, time.Minute),
+		"ttlwheel": strategies.NewTtlCache[int, int](64, time.Minute, strategies.WithTimingWheel[int, int](time.Millisecond)),
+	}
+}
+
+// TestConcurrentAccess hammers every strategy from several goroutines; run with -race to detect unsynchronized access
+func TestConcurrentAccess(t *testing.T) {
+	const (
+		workers    = 8
+		iterations = 2000
+	)
+
+	for name, factory := range concurrentFactories() {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+
+			var evictions atomic.Int64
+			c.(cache.ObservableCache[int, int]).OnEvent(func(event cache.Event[int, int]) {
+				if event.Type == cache.EventTypeEviction {
+					evictions.Add(1)
+				}
+			})
+
+			var wg sync.WaitGroup
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < iterations; i++ {
+						key := (w*iterations + i) % 256
+						switch {
+						case i%10 == 0:
+							_ = c.Delete(key)
+						case i%10 == 1:
+							c.Range(func(k, v int) bool {
+								return k != key
+							})
+						case i%500 == 2:
+							c.(cache.ObservableCache[int, int]).OnEvent(func(cache.Event[int, int]) {})
+						default:
+							_ = c.Set(key, i)
+							_, _ = c.Get(key)
+						}
+					}
+				}(w)
+			}
+			wg.Wait()
+
+			size := 0
+			c.Range(func(int, int) bool {
+				size++
+				return true
+			})
+			assert.LessOrEqual(t, size, 64)
+			assert.Positive(t, evictions.Load())
+		})
+	}
+}
+
+func TestConcurrentClear(t *testing.T) {
+	for name, factory := range concurrentFactories() {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+
+			var wg sync.WaitGroup
+			for w := 0; w < 4; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < 500; i++ {
+						if w == 0 && i%50 == 0 {
+							c.Clear()
+							continue
+						}
+						_ = c.Set(i%100, i)
+						_, _ = c.Get(i % 100)
+					}
+				}(w)
+			}
+			wg.Wait()
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package strategies_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUnsubscribe tests that an unsubscribed listener stops receiving events
+func TestUnsubscribe(t *testing.T) {
+	c := strategies.NewLruCache[string, int](10)()
+	observable := c.(cache.ObservableCache[string, int])
+
+	var first, second int
+	sub := observable.OnEvent(func(cache.Event[string, int]) { first++ })
+	observable.OnEvent(func(cache.Event[string, int]) { second++ })
+
+	_ = c.Set("a", 1)
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	_ = c.Set("b", 2)
+
+	assert.Equal(t, 1, first)
+	assert.Equal(t, 2, second)
+}
+
+// TestAsyncDispatcherAllowsReentrantListeners tests that asynchronous listeners may call back into the cache
+func TestAsyncDispatcherAllowsReentrantListeners(t *testing.T) {
+	dispatcher := cache.NewAsyncEventDispatcher[string, int](16, cache.OverflowBlock)
+	c := strategies.NewLruCache[string, int](1,
+		strategies.WithConcurrency[string, int](),
+		strategies.WithEventDispatcher(dispatcher),
+	)()
+
+	restored := make(chan struct{})
+	c.(cache.ObservableCache[string, int]).OnEvent(func(event cache.Event[string, int]) {
+		if event.Type == cache.EventTypeEviction && event.Key == "a" {
+			// A synchronous listener would deadlock here: the cache is still locked by the Set that evicted "a"
+			_, _ = c.Get("b")
+			close(restored)
+		}
+	})
+
+	_ = c.Set("a", 1)
+	_ = c.Set("b", 2)
+
+	select {
+	case <-restored:
+	case <-time.After(time.Second):
+		t.Fatal("listener was not called")
+	}
+}
+
+// TestAsyncDispatcherPreservesOrder tests that queued events reach listeners in emission order
+func TestAsyncDispatcherPreservesOrder(t *testing.T) {
+	dispatcher := cache.NewAsyncEventDispatcher[int, int](4, cache.OverflowBlock)
+	c := strategies.NewFifoCache[int, int](1000, strategies.WithEventDispatcher(dispatcher))()
+
+	var mu sync.Mutex
+	var keys []int
+	done := make(chan struct{})
+	c.(cache.ObservableCache[int, int]).OnEvent(func(event cache.Event[int, int]) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, event.Key)
+		if len(keys) == 100 {
+			close(done)
+		}
+	})
+
+	for i := 0; i < 100; i++ {
+		_ = c.Set(i, i)
+	}
+	<-done
+
+	for i, key := range keys {
+		require.Equal(t, i, key)
+	}
+}
+
+// TestAsyncDispatcherDropsOnOverflow tests that a full drop-policy buffer counts discarded events
+func TestAsyncDispatcherDropsOnOverflow(t *testing.T) {
+	dispatcher := cache.NewAsyncEventDispatcher[int, int](1, cache.OverflowDrop)
+	c := strategies.NewLruCache[int, int](10, strategies.WithEventDispatcher(dispatcher))()
+
+	release := make(chan struct{})
+	var delivered atomic.Int64
+	c.(cache.ObservableCache[int, int]).OnEvent(func(cache.Event[int, int]) {
+		<-release
+		delivered.Add(1)
+	})
+
+	for i := 0; i < 10; i++ {
+		_ = c.Set(i, i)
+	}
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		return delivered.Load()+dispatcher.Dropped() == 10
+	}, time.Second, time.Millisecond)
+	assert.Positive(t, dispatcher.Dropped())
+}
+
+// TestSharedDispatcher tests that one dispatcher can serve several caches
+func TestSharedDispatcher(t *testing.T) {
+	dispatcher := cache.NewEventDispatcher[string, int]()
+	lru := strategies.NewLruCache[string, int](10, strategies.WithEventDispatcher(dispatcher))()
+	lfu := strategies.NewLfuCache[string, int](10, strategies.WithEventDispatcher(dispatcher))()
+
+	inserts := 0
+	dispatcher.Subscribe(func(event cache.Event[string, int]) {
+		if event.Type == cache.EventTypeInsert {
+			inserts++
+		}
+	})
+
+	_ = lru.Set("a", 1)
+	_ = lfu.Set("b", 2)
+	assert.Equal(t, 2, inserts)
+}
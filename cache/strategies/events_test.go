@@ -0,0 +1,113 @@
+package strategies_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/clock/clocktest"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordEvents(t *testing.T, c cache.IterableCache[string, int]) *[]cache.Event[string, int] {
+	observable, ok := c.(cache.ObservableCache[string, int])
+	require.True(t, ok, "strategy should implement ObservableCache")
+
+	var events []cache.Event[string, int]
+	observable.OnEvent(func(event cache.Event[string, int]) {
+		events = append(events, event)
+	})
+	return &events
+}
+
+// TestEventModel tests that every strategy reports the same lifecycle events
+func TestEventModel(t *testing.T) {
+	factories := map[string]strategies.CacheFactory[string, int]{
+		"lru":      strategies.NewLruCache[string, int](2),
+		"lfu":      strategies.NewLfuCache[string, int](2),
+		"fifo":     strategies.NewFifoCache[string, int](2),
+		"arc":      strategies.NewArcCache[string, int](2),
+		"tinylfu":  strategies.NewTinyLfuCache[string, int](2),
+		"sieve":    strategies.NewSieveCache[string, int](2),
+		"s3fifo":   strategies.NewS3FifoCache[string, int](2),
+		"clock":    strategies.NewClockCache[string, int](2),
+		"clockpro": strategies.NewClockProCache[string, int](2),
+		"slru":     strategies.NewSlruCache[string, int](2, 0.5),
+		"2q":       strategies.NewTwoQueueCache[string, int](2),
+		"lirs":     strategies.NewLirsCache[string, int](2, 0.5),
+		"gdsf":     strategies.NewGdsfCache[string, int](2, nil),
+		"car":      strategies.NewCarCache[string, int](2),
+		"sampled":  strategies.NewSampledCache[string, int](2, 5, strategies.OldestAccess[string, int]),
+		"ttl":      strategies.NewTtlCache[string, int](2, time.Minute),
+		"ttlwheel": strategies.NewTtlCache[string, int](2, time.Minute, strategies.WithTimingWheel[string, int](time.Second)),
+	}
+
+	for name, factory := range factories {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+			events := recordEvents(t, c)
+
+			_ = c.Set("a", 1)
+			_ = c.Set("a", 2)
+			_, _ = c.Get("a")
+			_, _ = c.Get("missing")
+			_ = c.Set("b", 3)
+			_ = c.Set("c", 4)
+			_ = c.Delete("c")
+			c.Clear()
+
+			var types []cache.EventType
+			for _, event := range *events {
+				types = append(types, event.Type)
+			}
+			assert.Equal(t, []cache.EventType{
+				cache.EventTypeInsert,
+				cache.EventTypeUpdate,
+				cache.EventTypeHit,
+				cache.EventTypeMiss,
+				cache.EventTypeInsert,
+				cache.EventTypeEviction,
+				cache.EventTypeInsert,
+				cache.EventTypeDelete,
+				cache.EventTypeClear,
+			}, types)
+
+			update := (*events)[1]
+			assert.Equal(t, "a", update.Key)
+			assert.Equal(t, 2, update.Value)
+
+			eviction := (*events)[5]
+			assert.Equal(t, cache.EvictionReasonCapacity, eviction.Reason)
+
+			deletion := (*events)[7]
+			assert.Equal(t, "c", deletion.Key)
+			assert.Equal(t, 4, deletion.Value)
+		})
+	}
+}
+
+// TestTTLCacheExpiryReason tests that expiry is distinguishable from capacity eviction
+func TestTTLCacheExpiryReason(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	c := strategies.NewTtlCache[string, int](1, time.Minute, strategies.WithClock[string, int](clk))()
+	ttlCache := c.(strategies.TTLCache[string, int])
+	events := recordEvents(t, c)
+
+	_ = ttlCache.SetWithTTL("short", 1, 10*time.Millisecond)
+	clk.Advance(10 * time.Millisecond)
+	_, err := c.Get("short")
+	require.Error(t, err)
+
+	_ = c.Set("a", 1)
+	_ = c.Set("b", 2)
+
+	var reasons []cache.EvictionReason
+	for _, event := range *events {
+		if event.Type == cache.EventTypeEviction {
+			reasons = append(reasons, event.Reason)
+		}
+	}
+	assert.Equal(t, []cache.EvictionReason{cache.EvictionReasonExpired, cache.EvictionReasonCapacity}, reasons)
+}
@@ -0,0 +1,157 @@
+package strategies
+
+import (
+	"container/heap"
+	"github.com/kimvlry/caching/cache/clock"
+	"github.com/kimvlry/caching/cache/strategies/priority_heap"
+	"github.com/kimvlry/caching/cache/strategies/priority_heap/heap_item"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// expiry tracks per-entry deadlines for the strategies that evict by their own policy,
+// so time to live can be combined with any of them. Only entries with a deadline are tracked.
+type expiry[K comparable, V any] struct {
+	defaultTTL time.Duration
+	items      map[K]heap_item.Item[K, V]
+	deadlines  *priority_heap.MinHeap[K, V]
+	clock      clock.Clock
+	jitter     ttlJitter
+
+	stopCleaner chan struct{}
+	cleanerOnce sync.Once
+}
+
+func newExpiry[K comparable, V any](cfg *config[K, V]) *expiry[K, V] {
+	return &expiry[K, V]{
+		defaultTTL: cfg.defaultTTL,
+		items:      make(map[K]heap_item.Item[K, V]),
+		deadlines:  priority_heap.NewMinHeap[K, V](),
+		clock:      cfg.timeSource(),
+		jitter:     newTTLJitter(cfg.ttlJitter),
+	}
+}
+
+// ttlJitter spreads ttls randomly within a fraction of them either way
+type ttlJitter struct {
+	fraction float64
+	rand     *rand.Rand
+}
+
+func newTTLJitter(fraction float64) ttlJitter {
+	if fraction <= 0 {
+		return ttlJitter{}
+	}
+	return ttlJitter{fraction: fraction, rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+func (j ttlJitter) apply(ttl time.Duration) time.Duration {
+	if j.rand == nil {
+		return ttl
+	}
+	return time.Duration(float64(ttl) * (1 + j.fraction*(2*j.rand.Float64()-1)))
+}
+
+// track gives key a deadline ttl from now, replacing any previous one
+func (e *expiry[K, V]) track(key K, ttl time.Duration) {
+	deadline := e.clock.Now().Add(e.jitter.apply(ttl))
+	if item, exists := e.items[key]; exists {
+		item.SetPriority(deadline.UnixNano())
+		heap.Fix(e.deadlines, item.GetIndex())
+		return
+	}
+	var zero V
+	item := heap_item.NewTTLHeapItemAt(key, zero, deadline)
+	e.items[key] = item
+	heap.Push(e.deadlines, item)
+}
+
+// trackDefault applies the default ttl to key, or removes its deadline when there is no default
+func (e *expiry[K, V]) trackDefault(key K) {
+	if e.defaultTTL > 0 {
+		e.track(key, e.defaultTTL)
+	} else {
+		e.forget(key)
+	}
+}
+
+// trackStored applies the default ttl to the keys of a batch that made it into stored
+func trackStored[K comparable, V any, B any, S any](e *expiry[K, V], batch map[K]B, stored map[K]S) {
+	for key := range batch {
+		if _, ok := stored[key]; ok {
+			e.trackDefault(key)
+		}
+	}
+}
+
+func (e *expiry[K, V]) forget(key K) {
+	if item, exists := e.items[key]; exists {
+		heap.Remove(e.deadlines, item.GetIndex())
+		delete(e.items, key)
+	}
+}
+
+// deadline returns the time key expires at, if it has one
+func (e *expiry[K, V]) deadline(key K) (time.Time, bool) {
+	item, exists := e.items[key]
+	if !exists {
+		return time.Time{}, false
+	}
+	return time.Unix(0, item.GetPriority()), true
+}
+
+func (e *expiry[K, V]) expired(key K) bool {
+	item, exists := e.items[key]
+	return exists && e.clock.Now().UnixNano() >= item.GetPriority()
+}
+
+// popExpired stops tracking and returns every key whose deadline has passed
+func (e *expiry[K, V]) popExpired() []K {
+	now := e.clock.Now()
+	var keys []K
+	for {
+		top := e.deadlines.Peek()
+		if top == nil || now.UnixNano() < top.GetPriority() {
+			return keys
+		}
+		heap.Pop(e.deadlines)
+		delete(e.items, top.GetKey())
+		keys = append(keys, top.GetKey())
+	}
+}
+
+func (e *expiry[K, V]) reset() {
+	e.items = make(map[K]heap_item.Item[K, V])
+	e.deadlines = priority_heap.NewMinHeap[K, V]()
+}
+
+// startCleaner calls purge every cleanup interval of cfg until stop is called
+func (e *expiry[K, V]) startCleaner(cfg *config[K, V], purge func()) {
+	interval := cfg.cleanupInterval
+	if interval <= 0 {
+		return
+	}
+	e.cleanerOnce.Do(func() {
+		e.stopCleaner = make(chan struct{})
+		ticker := e.clock.NewTicker(interval)
+		go func(stop <-chan struct{}) {
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C():
+					purge()
+				case <-stop:
+					return
+				}
+			}
+		}(e.stopCleaner)
+	})
+}
+
+func (e *expiry[K, V]) stop() {
+	if e.stopCleaner != nil {
+		close(e.stopCleaner)
+		e.stopCleaner = nil
+	}
+}
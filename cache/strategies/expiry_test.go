@@ -0,0 +1,182 @@
+package strategies_test
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/clock/clocktest"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func expiringFactories(capacity int, opts ...strategies.Option[string, int]) map[string]strategies.CacheFactory[string, int] {
+	return map[string]strategies.CacheFactory[string, int]{
+		"lru":     strategies.NewLruCache[string, int](capacity, opts...),
+		"lfu":     strategies.NewLfuCache[string, int](capacity, opts...),
+		"fifo":    strategies.NewFifoCache[string, int](capacity, opts...),
+		"arc":     strategies.NewArcCache[string, int](capacity, opts...),
+		"sampled": strategies.NewSampledCache[string, int](capacity, 5, strategies.OldestAccess[string, int], opts...),
+	}
+}
+
+// TestSetWithTTL tests that every policy drops entries lazily once their own ttl has passed
+func TestSetWithTTL(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	for name, factory := range expiringFactories(10, strategies.WithClock[string, int](clk)) {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+			ttlCache, ok := c.(strategies.TTLCache[string, int])
+			require.True(t, ok, "strategy should implement TTLCache")
+			events := recordEvents(t, c)
+
+			require.NoError(t, ttlCache.SetWithTTL("short", 1, 10*time.Millisecond))
+			require.NoError(t, c.Set("forever", 2))
+			clk.Advance(10 * time.Millisecond)
+
+			var keys []string
+			c.Range(func(key string, _ int) bool {
+				keys = append(keys, key)
+				return true
+			})
+			assert.Equal(t, []string{"forever"}, keys)
+
+			_, err := c.Get("short")
+			assert.Error(t, err)
+			value, err := c.Get("forever")
+			require.NoError(t, err)
+			assert.Equal(t, 2, value)
+
+			var expired []string
+			for _, event := range *events {
+				if event.Type == cache.EventTypeEviction && event.Reason == cache.EvictionReasonExpired {
+					expired = append(expired, event.Key)
+				}
+			}
+			assert.Equal(t, []string{"short"}, expired)
+		})
+	}
+}
+
+// TestDefaultTTL tests that Set applies the default ttl and that a later Set renews it
+func TestDefaultTTL(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	opts := []strategies.Option[string, int]{
+		strategies.WithExpiration[string, int](30*time.Millisecond, 0),
+		strategies.WithClock[string, int](clk),
+	}
+	for name, factory := range expiringFactories(10, opts...) {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+			assert.Equal(t, 30*time.Millisecond, c.(strategies.TTLCache[string, int]).GetDefaultTTL())
+
+			_ = c.Set("a", 1)
+			_ = c.Set("b", 2)
+			clk.Advance(20 * time.Millisecond)
+			_ = c.Set("b", 3)
+			clk.Advance(10 * time.Millisecond)
+
+			_, err := c.Get("a")
+			assert.Error(t, err)
+			value, err := c.Get("b")
+			require.NoError(t, err)
+			assert.Equal(t, 3, value)
+		})
+	}
+}
+
+// TestTTLJitter tests that every policy spreads ttls within the jitter fraction
+func TestTTLJitter(t *testing.T) {
+	clk := clocktest.NewFakeClock(time.Now())
+	opts := []strategies.Option[string, int]{
+		strategies.WithExpiration[string, int](100*time.Second, 0),
+		strategies.WithTTLJitter[string, int](0.1),
+		strategies.WithClock[string, int](clk),
+	}
+	for name, factory := range expiringFactories(100, opts...) {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+			for i := 0; i < 100; i++ {
+				require.NoError(t, c.Set(fmt.Sprint(i), i))
+			}
+			clk.Advance(89 * time.Second)
+			assert.Len(t, keysOf(c), 100)
+			clk.Advance(11 * time.Second)
+			alive := len(keysOf(c))
+			assert.True(t, alive > 0 && alive < 100, "some entries should outlive the nominal ttl, %d did", alive)
+			clk.Advance(11 * time.Second)
+			assert.Empty(t, keysOf(c))
+		})
+	}
+}
+
+// TestExpirationCleaner tests that the background cleaner removes expired entries without being asked
+func TestExpirationCleaner(t *testing.T) {
+	opts := strategies.WithExpiration[string, int](10*time.Millisecond, 5*time.Millisecond)
+	for name, factory := range expiringFactories(10, opts) {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+			defer c.(interface{ Stop() }).Stop()
+
+			var mu sync.Mutex
+			var expired []string
+			c.(cache.ObservableCache[string, int]).OnEvent(func(event cache.Event[string, int]) {
+				if event.Type == cache.EventTypeEviction && event.Reason == cache.EvictionReasonExpired {
+					mu.Lock()
+					defer mu.Unlock()
+					expired = append(expired, event.Key)
+				}
+			})
+
+			_ = c.Set("a", 1)
+			_ = c.Set("b", 2)
+
+			assert.Eventually(t, func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(expired) == 2
+			}, time.Second, time.Millisecond)
+		})
+	}
+}
+
+// TestExpirationKeepsEvictionPolicy tests that capacity eviction still follows the policy when entries carry a ttl
+func TestExpirationKeepsEvictionPolicy(t *testing.T) {
+	opts := strategies.WithExpiration[string, int](time.Minute, 0)
+
+	lru := strategies.NewLruCache[string, int](2, opts)()
+	_ = lru.Set("a", 1)
+	_ = lru.Set("b", 2)
+	_, _ = lru.Get("a")
+	_ = lru.Set("c", 3)
+	_, err := lru.Get("b")
+	assert.Error(t, err, "lru should evict the least recently used entry")
+
+	lfu := strategies.NewLfuCache[string, int](2, opts)()
+	_ = lfu.Set("a", 1)
+	_ = lfu.Set("b", 2)
+	_, _ = lfu.Get("b")
+	_, _ = lfu.Get("b")
+	_, _ = lfu.Get("a")
+	_ = lfu.Set("c", 3)
+	_, err = lfu.Get("a")
+	assert.Error(t, err, "lfu should evict the least frequently used entry")
+
+	fifo := strategies.NewFifoCache[string, int](2, opts)()
+	_ = fifo.(strategies.TTLCache[string, int]).SetWithTTL("a", 1, time.Hour)
+	_ = fifo.Set("b", 2)
+	_ = fifo.Set("c", 3)
+	_, err = fifo.Get("a")
+	assert.Error(t, err, "fifo should evict the oldest entry, not the one expiring first")
+
+	arc := strategies.NewArcCache[string, int](2, opts)()
+	_ = arc.Set("a", 1)
+	_, _ = arc.Get("a")
+	_ = arc.Set("b", 2)
+	_ = arc.Set("c", 3)
+	_, err = arc.Get("a")
+	assert.NoError(t, err, "arc should keep the frequently used entry")
+}
@@ -8,32 +8,130 @@ import (
 // CacheFactory is an alias for cache factory function
 type CacheFactory[K comparable, V any] func() cache.IterableCache[K, V]
 
-func NewLruCache[K comparable, V any](capacity int) CacheFactory[K, V] {
+func NewLruCache[K comparable, V any](capacity int, opts ...Option[K, V]) CacheFactory[K, V] {
 	return func() cache.IterableCache[K, V] {
-		return newLruCache[K, V](capacity)
+		return newLruCache[K, V](capacity, opts...)
 	}
 }
 
-func NewLfuCache[K comparable, V any](capacity int) CacheFactory[K, V] {
+func NewLfuCache[K comparable, V any](capacity int, opts ...Option[K, V]) CacheFactory[K, V] {
 	return func() cache.IterableCache[K, V] {
-		return newLfuCache[K, V](capacity)
+		return newLfuCache[K, V](capacity, opts...)
 	}
 }
 
-func NewTtlCache[K comparable, V any](capacity int, ttl time.Duration) CacheFactory[K, V] {
+// NewTtlCache builds a cache that is always safe for concurrent use, since its background evictor shares it
+func NewTtlCache[K comparable, V any](capacity int, ttl time.Duration, opts ...Option[K, V]) CacheFactory[K, V] {
 	return func() cache.IterableCache[K, V] {
-		return newTtlCache[K, V](capacity, ttl)
+		return newTtlCache[K, V](capacity, ttl, opts...)
 	}
 }
 
-func NewFifoCache[K comparable, V any](capacity int) CacheFactory[K, V] {
+func NewFifoCache[K comparable, V any](capacity int, opts ...Option[K, V]) CacheFactory[K, V] {
 	return func() cache.IterableCache[K, V] {
-		return newFifoCache[K, V](capacity)
+		return newFifoCache[K, V](capacity, opts...)
 	}
 }
 
-func NewArcCache[K comparable, V any](capacity int) CacheFactory[K, V] {
+func NewArcCache[K comparable, V any](capacity int, opts ...Option[K, V]) CacheFactory[K, V] {
 	return func() cache.IterableCache[K, V] {
-		return newArcCache[K, V](capacity)
+		return newArcCache[K, V](capacity, opts...)
+	}
+}
+
+// NewTinyLfuCache builds a Window-TinyLFU cache: a scan-resistant policy that admits new entries into its main region
+// only if they are used more often than the entries they would replace
+func NewTinyLfuCache[K comparable, V any](capacity int, opts ...Option[K, V]) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newTinyLfuCache[K, V](capacity, opts...)
+	}
+}
+
+// NewSieveCache builds a SIEVE cache: a FIFO queue with visited bits and a sweeping hand, reaching LRU-like hit rates
+// without moving entries on hits
+func NewSieveCache[K comparable, V any](capacity int, opts ...Option[K, V]) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newSieveCache[K, V](capacity, opts...)
+	}
+}
+
+// NewS3FifoCache builds an S3-FIFO cache: a small probationary FIFO queue, a main FIFO queue and a ghost queue
+// of recently evicted keys
+func NewS3FifoCache[K comparable, V any](capacity int, opts ...Option[K, V]) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newS3FifoCache[K, V](capacity, opts...)
+	}
+}
+
+// NewClockCache builds a CLOCK (second chance) cache on a fixed-size array: hits set a referenced bit
+// instead of reordering entries
+func NewClockCache[K comparable, V any](capacity int, opts ...Option[K, V]) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newClockCache[K, V](capacity, opts...)
+	}
+}
+
+// NewClockProCache builds a scan-resistant CLOCK-Pro cache on a fixed-size array, adapting the room given to
+// pages seen once to the reuse it observes
+func NewClockProCache[K comparable, V any](capacity int, opts ...Option[K, V]) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newClockProCache[K, V](capacity, opts...)
+	}
+}
+
+// NewSlruCache builds a Segmented LRU cache whose protected segment holds up to protectedRatio of the capacity
+// (0.8 is a common choice); the rest is left to the probationary segment new entries start in
+func NewSlruCache[K comparable, V any](capacity int, protectedRatio float64, opts ...Option[K, V]) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newSlruCache[K, V](capacity, protectedRatio, opts...)
+	}
+}
+
+// NewTwoQueueCache builds a 2Q cache: new entries wait in a FIFO queue and only enter the main LRU queue
+// if they are referenced again soon after leaving it
+func NewTwoQueueCache[K comparable, V any](capacity int, opts ...Option[K, V]) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newTwoQueueCache[K, V](capacity, opts...)
+	}
+}
+
+// NewLirsCache builds a LIRS cache that keeps hirRatio of the capacity (around 0.01 in the LIRS paper) for entries
+// without a proven short reuse distance, and the rest for the entries reused most closely
+func NewLirsCache[K comparable, V any](capacity int, hirRatio float64, opts ...Option[K, V]) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newLirsCache[K, V](capacity, hirRatio, opts...)
+	}
+}
+
+// NewGdsfCache builds a Greedy-Dual-Size-Frequency cache, which evicts the entries with the lowest
+// frequency*cost/size first. cost gives the recomputation cost of an entry, 1 for every entry if nil;
+// the size comes from WithWeigher, or can be given per entry along with the cost through CostAwareCache.SetWithCost.
+func NewGdsfCache[K comparable, V any](
+	capacity int,
+	cost func(key K, value V) float64,
+	opts ...Option[K, V],
+) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newGdsfCache[K, V](capacity, cost, opts...)
+	}
+}
+
+// NewCarCache builds a CAR (Clock with Adaptive Replacement) cache: ARC's adaptive split between recency and frequency
+// on two clocks, so hits only set a referenced bit instead of moving entries between lists
+func NewCarCache[K comparable, V any](capacity int, opts ...Option[K, V]) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newCarCache[K, V](capacity, opts...)
+	}
+}
+
+// NewSampledCache builds a cache that evicts like Redis: it draws sampleSize random entries (Redis uses 5) and evicts
+// the one score ranks lowest, such as OldestAccess for approximate LRU. A nil score evicts a random entry.
+func NewSampledCache[K comparable, V any](
+	capacity, sampleSize int,
+	score Scorer[K, V],
+	opts ...Option[K, V],
+) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newSampledCache[K, V](capacity, sampleSize, score, opts...)
 	}
 }
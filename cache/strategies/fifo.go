@@ -3,65 +3,219 @@ package strategies
 import (
 	"github.com/kimvlry/caching/cache"
 	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+	"time"
 )
 
 // fifoCache implements a First In, First Out cache
 type fifoCache[K comparable, V any] struct {
 	capacity int
+	weight   int64
+	weigher  weigher[K, V]
+	weights  map[K]int64 // weight of each entry, only kept with a weigher
 	data     map[K]V
 	keys     []K
+	expiry   *expiry[K, V]
+	mu       sync.Locker
 
-	eventCallbacks []func(cache.Event[K, V])
+	events *cache.EventDispatcher[K, V]
 }
 
-func newFifoCache[K comparable, V any](capacity int) cache.IterableCache[K, V] {
-	return &fifoCache[K, V]{
+func newFifoCache[K comparable, V any](capacity int, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	cfg := newConfig(opts)
+	f := &fifoCache[K, V]{
 		capacity: capacity,
-		data:     make(map[K]V, capacity),
-		keys:     make([]K, 0, capacity),
+		weigher:  cfg.weigher,
+		data:     make(map[K]V, cfg.weigher.sizeHint(capacity)),
+		keys:     make([]K, 0, cfg.weigher.sizeHint(capacity)),
+		expiry:   newExpiry(cfg),
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
 	}
+	if f.weigher != nil {
+		f.weights = make(map[K]int64)
+	}
+	f.expiry.startCleaner(cfg, f.purgeExpired)
+	return f
 }
 
 // Get retrieves a value by key. If key not found, returns zero value and error
 func (f *fifoCache[K, V]) Get(key K) (V, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.get(key)
+}
+
+func (f *fifoCache[K, V]) get(key K) (V, error) {
+	if f.expiry.expired(key) {
+		f.expire([]K{key})
+	}
 	if value, exists := f.data[key]; exists {
+		f.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: value})
 		return value, nil
 	}
+	f.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
 	var zero V
 	return zero, common.ErrKeyNotFound
 }
 
 // Set adds or updates a key-value pair. If cache is full, the oldest pq_item gets evicted (first in)
 func (f *fifoCache[K, V]) Set(key K, value V) error {
-	if _, exists := f.data[key]; exists {
-		f.data[key] = value
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.set(key, value); err != nil {
+		return err
+	}
+	f.expiry.trackDefault(key)
+	return nil
+}
+
+// SetWithTTL stores the value and lets it expire after ttl, even if it is not the oldest entry yet
+func (f *fifoCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.set(key, value); err != nil {
+		return err
+	}
+	f.expiry.track(key, ttl)
+	return nil
+}
+
+func (f *fifoCache[K, V]) GetDefaultTTL() time.Duration {
+	return f.expiry.defaultTTL
+}
+
+func (f *fifoCache[K, V]) set(key K, value V) error {
+	weight, err := f.weigher.admit(key, value, f.capacity)
+	if err != nil {
+		return err
+	}
+	if f.update(key, value, weight) {
+		// The new value may weigh more than the old one
+		f.evict(0)
 		return nil
 	}
+	f.evict(weight)
+	f.insert(key, value, weight)
+	return nil
+}
+
+// update overwrites the value of a present key without changing its position in the queue
+func (f *fifoCache[K, V]) update(key K, value V, weight int64) bool {
+	if _, exists := f.data[key]; !exists {
+		return false
+	}
+	f.data[key] = value
+	f.weight += weight - f.weightOf(key)
+	if f.weights != nil {
+		f.weights[key] = weight
+	}
+	f.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+	return true
+}
 
-	if len(f.data) >= f.capacity {
-		oldestKey := f.keys[0]
+func (f *fifoCache[K, V]) insert(key K, value V, weight int64) {
+	f.data[key] = value
+	f.keys = append(f.keys, key)
+	f.weight += weight
+	if f.weights != nil {
+		f.weights[key] = weight
+	}
+	f.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+}
+
+func (f *fifoCache[K, V]) weightOf(key K) int64 {
+	if f.weights == nil {
+		return 1
+	}
+	return f.weights[key]
+}
+
+// forget drops the bookkeeping of an entry that left the cache
+func (f *fifoCache[K, V]) forget(key K) {
+	f.expiry.forget(key)
+	f.weight -= f.weightOf(key)
+	delete(f.weights, key)
+}
+
+// evict removes the oldest entries until weight more fits into the capacity
+func (f *fifoCache[K, V]) evict(weight int64) {
+	n := 0
+	for ; n < len(f.keys) && f.weight+weight > int64(f.capacity); n++ {
+		oldestKey := f.keys[n]
+		value := f.data[oldestKey]
 		delete(f.data, oldestKey)
-		f.keys = f.keys[1:]
+		f.forget(oldestKey)
 
-		f.emit(cache.Event[K, V]{
-			Type:  cache.EventTypeEviction,
-			Key:   oldestKey,
-			Value: value,
+		f.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    oldestKey,
+			Value:  value,
+			Reason: cache.EvictionReasonCapacity,
 		})
 	}
+	f.keys = f.keys[n:]
+}
 
-	f.data[key] = value
-	f.keys = append(f.keys, key)
-	return nil
+// expire drops entries whose deadline has passed, with a single pass over the queue
+func (f *fifoCache[K, V]) expire(keys []K) {
+	expired := 0
+	for _, key := range keys {
+		value, exists := f.data[key]
+		if !exists {
+			continue
+		}
+		delete(f.data, key)
+		f.forget(key)
+		expired++
+		f.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    key,
+			Value:  value,
+			Reason: cache.EvictionReasonExpired,
+		})
+	}
+	if expired > 0 {
+		f.compact()
+	}
+}
+
+// purgeExpired is called by the background cleaner
+func (f *fifoCache[K, V]) purgeExpired() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.expire(f.expiry.popExpired())
+}
+
+// compact drops the keys that are no longer stored from the queue
+func (f *fifoCache[K, V]) compact() {
+	remaining := f.keys[:0]
+	for _, k := range f.keys {
+		if _, exists := f.data[k]; exists {
+			remaining = append(remaining, k)
+		}
+	}
+	f.keys = remaining
 }
 
 // Delete removes a key-value pair. Returns error if key not found
 func (f *fifoCache[K, V]) Delete(key K) error {
-	if _, exists := f.data[key]; !exists {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.delete(key)
+}
+
+func (f *fifoCache[K, V]) delete(key K) error {
+	value, exists := f.data[key]
+	if !exists {
 		return common.ErrKeyNotFound
 	}
 
 	delete(f.data, key)
+	f.forget(key)
+	f.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: value})
 
 	for i, k := range f.keys {
 		if k == key {
@@ -73,26 +227,88 @@ func (f *fifoCache[K, V]) Delete(key K) error {
 	return nil
 }
 
-// Clear removes all key-value pairs
-func (f *fifoCache[K, V]) Clear() {
-	f.data = make(map[K]V, f.capacity)
-	f.keys = make([]K, 0)
+func (f *fifoCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return getMany(keys, f.get)
 }
 
-func (f *fifoCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) {
-	f.eventCallbacks = append(f.eventCallbacks, callback)
+// SetMany updates present keys, then makes room for all new keys in a single eviction pass.
+// If the new keys outweigh the capacity, only as many of them as fit are stored.
+func (f *fifoCache[K, V]) SetMany(entries map[K]V) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	weights, err := weighMany(entries, f.weigher, f.capacity)
+	fresh, weight := fitting(freshKeys(entries, weights, f.update), weights, f.capacity)
+	f.evict(weight)
+	for _, key := range fresh {
+		f.insert(key, entries[key], weights[key])
+	}
+	trackStored(f.expiry, weights, f.data)
+	return err
 }
 
-func (f *fifoCache[K, V]) emit(event cache.Event[K, V]) {
-	for _, callback := range f.eventCallbacks {
-		callback(event)
+// DeleteMany removes the keys with a single pass over the queue
+func (f *fifoCache[K, V]) DeleteMany(keys []K) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	deleted := 0
+	for _, key := range keys {
+		if value, exists := f.data[key]; exists {
+			delete(f.data, key)
+			f.forget(key)
+			f.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: value})
+			deleted++
+		}
+	}
+	if deleted > 0 {
+		f.compact()
+	}
+	return deleted
+}
+
+// Clear removes all key-value pairs
+func (f *fifoCache[K, V]) Clear() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.data = make(map[K]V, f.weigher.sizeHint(f.capacity))
+	f.keys = make([]K, 0)
+	f.weight = 0
+	if f.weigher != nil {
+		f.weights = make(map[K]int64)
 	}
+	f.expiry.reset()
+	f.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
 }
 
 func (f *fifoCache[K, V]) Range(fn func(K, V) bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	for k, v := range f.data {
+		if f.expiry.expired(k) {
+			continue
+		}
 		if !fn(k, v) {
 			break
 		}
 	}
 }
+
+func (f *fifoCache[K, V]) Weight() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.weight
+}
+
+// Stop terminates the background cleaner started by WithExpiration
+func (f *fifoCache[K, V]) Stop() {
+	f.expiry.stop()
+}
+
+func (f *fifoCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return f.events.Subscribe(callback)
+}
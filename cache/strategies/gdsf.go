@@ -0,0 +1,268 @@
+package strategies
+
+import (
+	"container/heap"
+	"errors"
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"github.com/kimvlry/caching/cache/strategies/priority_heap"
+	"github.com/kimvlry/caching/cache/strategies/priority_heap/heap_item"
+	"math"
+	"sync"
+)
+
+// CostAwareCache is implemented by caches that weigh what an entry costs to recompute against the room it takes
+type CostAwareCache[K comparable, V any] interface {
+	cache.IterableCache[K, V]
+	// SetWithCost stores the value with the given size and recomputation cost instead of the measured ones
+	SetWithCost(key K, value V, size int64, cost float64) error
+}
+
+// gdsfCache implements Greedy-Dual-Size-Frequency replacement.
+// Every entry has the priority L + frequency*cost/size, and the entry with the lowest priority is evicted first,
+// so small entries that are expensive to recompute and often used stay longest. L is an inflation clock:
+// it rises to the priority of every evicted entry, so entries added later start above entries that stopped being used,
+// and old frequencies cannot keep stale entries around forever.
+type gdsfCache[K comparable, V any] struct {
+	capacity int
+	weight   int64
+	weigher  weigher[K, V]
+	coster   func(key K, value V) float64
+	clock    float64
+
+	data     map[K]*gdsfEntry[K, V]
+	priority *priority_heap.MinHeap[K, V] // lowest priority first
+	mu       sync.Locker
+
+	events *cache.EventDispatcher[K, V]
+}
+
+type gdsfEntry[K comparable, V any] struct {
+	item      heap_item.Item[K, V]
+	frequency int64
+	size      int64
+	cost      float64
+	priority  float64
+}
+
+func newGdsfCache[K comparable, V any](
+	capacity int,
+	cost func(key K, value V) float64,
+	opts ...Option[K, V],
+) cache.IterableCache[K, V] {
+	cfg := newConfig(opts)
+	return &gdsfCache[K, V]{
+		capacity: capacity,
+		weigher:  cfg.weigher,
+		coster:   cost,
+		data:     make(map[K]*gdsfEntry[K, V], cfg.weigher.sizeHint(capacity)),
+		priority: priority_heap.NewMinHeap[K, V](),
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
+	}
+}
+
+// heapPriority encodes a priority for the heap: the bits of non-negative floats sort like the numbers they encode
+func heapPriority(priority float64) int64 {
+	return int64(math.Float64bits(priority))
+}
+
+func (g *gdsfCache[K, V]) Get(key K) (V, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.get(key)
+}
+
+func (g *gdsfCache[K, V]) get(key K) (V, error) {
+	if e, exists := g.data[key]; exists {
+		e.frequency++
+		g.reprioritize(e)
+		value := e.item.GetValue()
+		g.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: value})
+		return value, nil
+	}
+
+	g.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+	var zero V
+	return zero, common.ErrKeyNotFound
+}
+
+// Set stores the value with the size given by the weigher, 1 without one, and the cost given by the cost function,
+// 1 without one
+func (g *gdsfCache[K, V]) Set(key K, value V) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.set(key, value)
+}
+
+// SetWithCost stores the value with an explicit size and recomputation cost.
+// The size counts against the capacity even without a weigher, and an entry larger than the capacity is rejected.
+func (g *gdsfCache[K, V]) SetWithCost(key K, value V, size int64, cost float64) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	size = max(size, 0)
+	if size > int64(g.capacity) {
+		return &common.EntryTooLargeError{Key: key, Weight: size, Capacity: int64(g.capacity)}
+	}
+	g.store(key, value, size, cost)
+	return nil
+}
+
+func (g *gdsfCache[K, V]) set(key K, value V) error {
+	size, err := g.weigher.admit(key, value, g.capacity)
+	if err != nil {
+		return err
+	}
+	cost := 1.0
+	if g.coster != nil {
+		cost = g.coster(key, value)
+	}
+	g.store(key, value, size, cost)
+	return nil
+}
+
+func (g *gdsfCache[K, V]) store(key K, value V, size int64, cost float64) {
+	cost = max(cost, 0)
+	if e, exists := g.data[key]; exists {
+		// Storing a present key is a reference too
+		g.weight += size - e.size
+		e.item.SetValue(value)
+		e.size, e.cost = size, cost
+		e.frequency++
+		g.reprioritize(e)
+		g.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+		// The new value may be larger than the old one
+		g.evict(0)
+		return
+	}
+
+	g.evict(size)
+	e := &gdsfEntry[K, V]{
+		item:      heap_item.NewPriorityHeapItem(key, value, 0),
+		frequency: 1,
+		size:      size,
+		cost:      cost,
+	}
+	e.priority = g.priorityOf(e)
+	e.item.SetPriority(heapPriority(e.priority))
+	g.data[key] = e
+	g.weight += size
+	heap.Push(g.priority, e.item)
+	g.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+}
+
+// priorityOf is the GDSF priority of an entry at the current clock.
+// Entries of size 0 count as size 1, rather than becoming impossible to evict.
+func (g *gdsfCache[K, V]) priorityOf(e *gdsfEntry[K, V]) float64 {
+	return g.clock + float64(e.frequency)*e.cost/float64(max(e.size, 1))
+}
+
+func (g *gdsfCache[K, V]) reprioritize(e *gdsfEntry[K, V]) {
+	e.priority = g.priorityOf(e)
+	e.item.SetPriority(heapPriority(e.priority))
+	heap.Fix(g.priority, e.item.GetIndex())
+}
+
+// evict removes the entries with the lowest priority until size more fits into the capacity,
+// raising the clock to the priority of each of them
+func (g *gdsfCache[K, V]) evict(size int64) {
+	for g.weight+size > int64(g.capacity) && g.priority.Len() > 0 {
+		item := heap.Pop(g.priority).(heap_item.Item[K, V])
+		e := g.data[item.GetKey()]
+		g.clock = e.priority
+		g.forget(e)
+
+		g.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    item.GetKey(),
+			Value:  item.GetValue(),
+			Reason: cache.EvictionReasonCapacity,
+		})
+	}
+}
+
+// forget drops an entry already taken out of the heap
+func (g *gdsfCache[K, V]) forget(e *gdsfEntry[K, V]) {
+	delete(g.data, e.item.GetKey())
+	g.weight -= e.size
+}
+
+func (g *gdsfCache[K, V]) Delete(key K) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.delete(key)
+}
+
+func (g *gdsfCache[K, V]) delete(key K) error {
+	e, exists := g.data[key]
+	if !exists {
+		return common.ErrKeyNotFound
+	}
+
+	heap.Remove(g.priority, e.item.GetIndex())
+	g.forget(e)
+	g.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: e.item.GetValue()})
+	return nil
+}
+
+func (g *gdsfCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return getMany(keys, g.get)
+}
+
+// SetMany stores the entries under a single lock acquisition.
+// Like ARC it adds entries one at a time, since every eviction advances the clock new priorities start from.
+func (g *gdsfCache[K, V]) SetMany(entries map[K]V) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var errs []error
+	for key, value := range entries {
+		if err := g.set(key, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (g *gdsfCache[K, V]) DeleteMany(keys []K) int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return deleteMany(keys, g.delete)
+}
+
+// Clear removes every entry and resets the clock
+func (g *gdsfCache[K, V]) Clear() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.data = make(map[K]*gdsfEntry[K, V], g.weigher.sizeHint(g.capacity))
+	g.priority = priority_heap.NewMinHeap[K, V]()
+	g.weight = 0
+	g.clock = 0
+	g.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits entries in no particular order
+func (g *gdsfCache[K, V]) Range(fn func(K, V) bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for key, e := range g.data {
+		if !fn(key, e.item.GetValue()) {
+			return
+		}
+	}
+}
+
+func (g *gdsfCache[K, V]) Weight() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.weight
+}
+
+func (g *gdsfCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return g.events.Subscribe(callback)
+}
@@ -0,0 +1,94 @@
+package strategies_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func evictedKeys(events []cache.Event[string, int]) []string {
+	var keys []string
+	for _, event := range events {
+		if event.Type == cache.EventTypeEviction {
+			keys = append(keys, event.Key)
+		}
+	}
+	return keys
+}
+
+// TestGdsfCache tests that entries cheap to recompute for their size are evicted first
+func TestGdsfCache(t *testing.T) {
+	c := strategies.NewGdsfCache[string, int](12, nil)()
+	gdsf := c.(strategies.CostAwareCache[string, int])
+	events := recordEvents(t, c)
+
+	require.NoError(t, gdsf.SetWithCost("costly", 1, 1, 10))
+	require.NoError(t, gdsf.SetWithCost("cheap", 2, 1, 1))
+	require.NoError(t, gdsf.SetWithCost("large", 3, 10, 20)) // priority 2: costly, but for a lot of room
+
+	require.NoError(t, gdsf.SetWithCost("new", 4, 1, 5))
+	assert.Equal(t, []string{"cheap"}, evictedKeys(*events))
+
+	require.NoError(t, gdsf.SetWithCost("new", 4, 2, 5))
+	assert.Equal(t, []string{"cheap", "large"}, evictedKeys(*events))
+	assert.ElementsMatch(t, []string{"costly", "new"}, keysOf(c))
+	assert.Equal(t, int64(3), c.(strategies.WeightedCache[string, int]).Weight())
+}
+
+// TestGdsfCacheCostFunction tests that Set takes the cost from the cost function and the size from the weigher
+func TestGdsfCacheCostFunction(t *testing.T) {
+	c := strategies.NewGdsfCache[string, int](4,
+		func(_ string, value int) float64 { return float64(value) },
+		strategies.WithWeigher(func(key string, _ int) int64 { return int64(len(key)) }),
+	)()
+	events := recordEvents(t, c)
+
+	require.NoError(t, c.Set("aa", 10)) // priority 5
+	require.NoError(t, c.Set("b", 2))   // priority 2
+	require.NoError(t, c.Set("c", 3))   // priority 3
+	require.NoError(t, c.Set("d", 2))
+	assert.Equal(t, []string{"b"}, evictedKeys(*events))
+
+	// Evicting b moved the clock to 2, so d starts at 4 although it costs as much as b did
+	require.NoError(t, c.Set("e", 1))
+	assert.Equal(t, []string{"b", "c"}, evictedKeys(*events))
+}
+
+// TestGdsfCacheInflation tests that the clock lets new entries overtake an entry that stopped being used
+func TestGdsfCacheInflation(t *testing.T) {
+	c := strategies.NewGdsfCache[string, int](2, nil)()
+	events := recordEvents(t, c)
+
+	require.NoError(t, c.Set("hot", 0))
+	for i := 0; i < 5; i++ {
+		_, err := c.Get("hot")
+		require.NoError(t, err)
+	}
+	// hot has priority 6, every new key starts one above the clock, which reaches the priority of the last victim
+	for i := 0; i < 8; i++ {
+		require.NoError(t, c.Set(fmt.Sprint(i), i))
+	}
+
+	evicted := evictedKeys(*events)
+	assert.Equal(t, []string{"0", "1", "2", "3", "4"}, evicted[:5])
+	assert.Contains(t, evicted[5:7], "hot")
+}
+
+// TestGdsfCacheRejectsOversized tests that SetWithCost rejects an entry larger than the whole capacity
+func TestGdsfCacheRejectsOversized(t *testing.T) {
+	c := strategies.NewGdsfCache[string, int](4, nil)()
+
+	err := c.(strategies.CostAwareCache[string, int]).SetWithCost("a", 1, 5, 1)
+	var tooLarge *common.EntryTooLargeError
+	require.True(t, errors.As(err, &tooLarge))
+	assert.Equal(t, int64(5), tooLarge.Weight)
+
+	_, err = c.Get("a")
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+}
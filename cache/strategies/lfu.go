@@ -1,98 +1,351 @@
 package strategies
 
 import (
-	"container/heap"
+	"container/list"
 	"github.com/kimvlry/caching/cache"
 	"github.com/kimvlry/caching/cache/strategies/common"
-	"github.com/kimvlry/caching/cache/strategies/priority_heap"
-	"github.com/kimvlry/caching/cache/strategies/priority_heap/heap_item"
+	"sync"
+	"time"
 )
 
-// TODO: optimize to O(1) with double hashing
-
+// lfuCache implements a Least Frequently Used cache in constant time.
+// Entries live in frequency buckets kept in ascending order of use count;
+// within a bucket they are ordered by recency, so ties are broken by evicting the least recently used entry.
 type lfuCache[K comparable, V any] struct {
 	capacity int
-	data     map[K]heap_item.Item[K, V]
-	keys     *priority_heap.MinHeap[K, V]
+	weight   int64
+	weigher  weigher[K, V]
+	data     map[K]*list.Element // elements of the bucket entry lists
+	buckets  *list.List          // *frequencyBucket, least frequently used first
+	expiry   *expiry[K, V]
+	mu       sync.Locker
+
+	agingPeriod int // uses between two halvings of every frequency, zero disables aging
+	uses        int
+
+	events *cache.EventDispatcher[K, V]
+}
+
+type frequencyBucket[K comparable, V any] struct {
+	frequency int
+	entries   *list.List // *lfuEntry, least recently used first
+}
 
-	eventCallbacks []func(cache.Event[K, V])
+type lfuEntry[K comparable, V any] struct {
+	key    K
+	value  V
+	weight int64
+	bucket *list.Element
 }
 
-func newLfuCache[K comparable, V any](capacity int) cache.IterableCache[K, V] {
-	return &lfuCache[K, V]{
+func newLfuCache[K comparable, V any](capacity int, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	cfg := newConfig(opts)
+	l := &lfuCache[K, V]{
 		capacity: capacity,
-		data:     make(map[K]heap_item.Item[K, V], capacity),
-		keys:     priority_heap.NewMinHeap[K, V](),
+		weigher:  cfg.weigher,
+		data:     make(map[K]*list.Element, cfg.weigher.sizeHint(capacity)),
+		buckets:  list.New(),
+		expiry:   newExpiry(cfg),
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
+
+		agingPeriod: cfg.agingPeriod,
 	}
+	l.expiry.startCleaner(cfg, l.purgeExpired)
+	return l
 }
 
 func (l *lfuCache[K, V]) Get(key K) (V, error) {
-	item, exists := l.data[key]
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.get(key)
+}
+
+func (l *lfuCache[K, V]) get(key K) (V, error) {
+	if l.expiry.expired(key) {
+		l.expire(key)
+	}
+	elem, exists := l.data[key]
 	if !exists {
+		l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
 		var zero V
 		return zero, common.ErrKeyNotFound
 	}
 
-	item.SetPriority(item.GetPriority() + 1)
-	heap.Fix(l.keys, item.GetIndex())
-
-	return item.GetValue(), nil
+	e := l.touch(elem)
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: e.value})
+	return e.value, nil
 }
 
 func (l *lfuCache[K, V]) Set(key K, value V) error {
-	if item, exists := l.data[key]; exists {
-		item.SetPriority(item.GetPriority() + 1)
-		item.SetValue(value)
-		heap.Fix(l.keys, item.GetIndex())
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.set(key, value); err != nil {
+		return err
+	}
+	l.expiry.trackDefault(key)
+	return nil
+}
+
+// SetWithTTL stores the value and lets it expire after ttl, however often it is used
+func (l *lfuCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.set(key, value); err != nil {
+		return err
+	}
+	l.expiry.track(key, ttl)
+	return nil
+}
+
+func (l *lfuCache[K, V]) GetDefaultTTL() time.Duration {
+	return l.expiry.defaultTTL
+}
+
+func (l *lfuCache[K, V]) set(key K, value V) error {
+	weight, err := l.weigher.admit(key, value, l.capacity)
+	if err != nil {
+		return err
+	}
+	if l.update(key, value, weight) {
+		// The new value may weigh more than the old one
+		l.evict(0)
 		return nil
 	}
+	l.evict(weight)
+	l.insert(key, value, weight)
+	return nil
+}
 
-	if len(l.data) >= l.capacity {
-		evicted := heap.Pop(l.keys).(heap_item.Item[K, V])
-		delete(l.data, evicted.GetKey())
+// update overwrites the value of a present key, counting the write as a use
+func (l *lfuCache[K, V]) update(key K, value V, weight int64) bool {
+	elem, exists := l.data[key]
+	if !exists {
+		return false
+	}
+	e := l.touch(elem)
+	l.weight += weight - e.weight
+	e.value, e.weight = value, weight
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+	return true
+}
+
+// touch moves an entry to the bucket of the next frequency, creating it if needed, and makes it its most recent entry
+func (l *lfuCache[K, V]) touch(elem *list.Element) *lfuEntry[K, V] {
+	e := elem.Value.(*lfuEntry[K, V])
+	current := e.bucket
+	frequency := current.Value.(*frequencyBucket[K, V]).frequency
 
-		l.emit(cache.Event[K, V]{
-			Type:  cache.EventTypeEviction,
-			Key:   evicted.GetKey(),
-			Value: evicted.GetValue(),
+	next := current.Next()
+	if next == nil || next.Value.(*frequencyBucket[K, V]).frequency != frequency+1 {
+		next = l.buckets.InsertAfter(newFrequencyBucket[K, V](frequency+1), current)
+	}
+	l.unlink(elem)
+	e.bucket = next
+	l.data[e.key] = next.Value.(*frequencyBucket[K, V]).entries.PushBack(e)
+	l.used()
+	return e
+}
+
+func (l *lfuCache[K, V]) insert(key K, value V, weight int64) {
+	first := l.buckets.Front()
+	if first == nil || first.Value.(*frequencyBucket[K, V]).frequency != 1 {
+		first = l.buckets.PushFront(newFrequencyBucket[K, V](1))
+	}
+	e := &lfuEntry[K, V]{key: key, value: value, weight: weight, bucket: first}
+	l.data[key] = first.Value.(*frequencyBucket[K, V]).entries.PushBack(e)
+	l.weight += weight
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+	l.used()
+}
+
+// used counts a use and ages the cache once the aging period is over
+func (l *lfuCache[K, V]) used() {
+	if l.agingPeriod <= 0 {
+		return
+	}
+	l.uses++
+	if l.uses >= l.agingPeriod {
+		l.uses = 0
+		l.age()
+	}
+}
+
+// age halves every frequency, keeping at least one use per entry.
+// Buckets that end up with the same frequency are merged in their previous order,
+// so entries that were used less often stay closer to eviction.
+func (l *lfuCache[K, V]) age() {
+	for bucket := l.buckets.Front(); bucket != nil; {
+		next := bucket.Next()
+		b := bucket.Value.(*frequencyBucket[K, V])
+		b.frequency = max(b.frequency/2, 1)
+
+		if prev := bucket.Prev(); prev != nil && prev.Value.(*frequencyBucket[K, V]).frequency == b.frequency {
+			target := prev.Value.(*frequencyBucket[K, V]).entries
+			for elem := b.entries.Front(); elem != nil; elem = elem.Next() {
+				e := elem.Value.(*lfuEntry[K, V])
+				e.bucket = prev
+				l.data[e.key] = target.PushBack(e)
+			}
+			l.buckets.Remove(bucket)
+		}
+		bucket = next
+	}
+}
+
+// evict removes the least frequently used entries, the least recently used first among equal frequencies,
+// until weight more fits into the capacity
+func (l *lfuCache[K, V]) evict(weight int64) {
+	for l.weight+weight > int64(l.capacity) && l.buckets.Len() > 0 {
+		victim := l.buckets.Front().Value.(*frequencyBucket[K, V]).entries.Front()
+		evicted := victim.Value.(*lfuEntry[K, V])
+		l.remove(evicted.key)
+
+		l.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    evicted.key,
+			Value:  evicted.value,
+			Reason: cache.EvictionReasonCapacity,
 		})
 	}
+}
 
-	item := heap_item.NewPriorityHeapItem(key, value, 1)
-	l.data[key] = item
-	heap.Push(l.keys, item)
-	return nil
+// expire drops an entry whose deadline has passed
+func (l *lfuCache[K, V]) expire(key K) {
+	if e, removed := l.remove(key); removed {
+		l.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    key,
+			Value:  e.value,
+			Reason: cache.EvictionReasonExpired,
+		})
+	}
 }
 
-func (l *lfuCache[K, V]) Delete(key K) error {
-	item, exists := l.data[key]
+// purgeExpired is called by the background cleaner
+func (l *lfuCache[K, V]) purgeExpired() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range l.expiry.popExpired() {
+		l.expire(key)
+	}
+}
+
+// remove unlinks an entry without emitting an event
+func (l *lfuCache[K, V]) remove(key K) (*lfuEntry[K, V], bool) {
+	elem, exists := l.data[key]
 	if !exists {
-		return common.ErrKeyNotFound
+		return nil, false
 	}
-	heap.Remove(l.keys, item.GetIndex())
+	l.unlink(elem)
 	delete(l.data, key)
-	return nil
+	l.expiry.forget(key)
+	e := elem.Value.(*lfuEntry[K, V])
+	l.weight -= e.weight
+	return e, true
 }
 
-func (l *lfuCache[K, V]) Clear() {
-	l.data = make(map[K]heap_item.Item[K, V])
-	l.keys = priority_heap.NewMinHeap[K, V]()
+// unlink takes an entry out of its bucket and drops the bucket once it is empty
+func (l *lfuCache[K, V]) unlink(elem *list.Element) {
+	bucket := elem.Value.(*lfuEntry[K, V]).bucket
+	entries := bucket.Value.(*frequencyBucket[K, V]).entries
+	entries.Remove(elem)
+	if entries.Len() == 0 {
+		l.buckets.Remove(bucket)
+	}
 }
 
-func (l *lfuCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) {
-	l.eventCallbacks = append(l.eventCallbacks, callback)
+func (l *lfuCache[K, V]) Delete(key K) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.delete(key)
 }
 
-func (l *lfuCache[K, V]) emit(event cache.Event[K, V]) {
-	for _, callback := range l.eventCallbacks {
-		callback(event)
+func (l *lfuCache[K, V]) delete(key K) error {
+	e, removed := l.remove(key)
+	if !removed {
+		return common.ErrKeyNotFound
 	}
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: e.value})
+	return nil
 }
 
+func (l *lfuCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return getMany(keys, l.get)
+}
+
+// SetMany updates present keys, then makes room for all new keys in a single eviction pass.
+// If the new keys outweigh the capacity, only as many of them as fit are stored.
+func (l *lfuCache[K, V]) SetMany(entries map[K]V) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	weights, err := weighMany(entries, l.weigher, l.capacity)
+	fresh, weight := fitting(freshKeys(entries, weights, l.update), weights, l.capacity)
+	l.evict(weight)
+	for _, key := range fresh {
+		l.insert(key, entries[key], weights[key])
+	}
+	trackStored(l.expiry, weights, l.data)
+	return err
+}
+
+func (l *lfuCache[K, V]) DeleteMany(keys []K) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return deleteMany(keys, l.delete)
+}
+
+func (l *lfuCache[K, V]) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.data = make(map[K]*list.Element, l.weigher.sizeHint(l.capacity))
+	l.buckets = list.New()
+	l.weight = 0
+	l.uses = 0
+	l.expiry.reset()
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits entries in eviction order: least frequently, then least recently used first
 func (l *lfuCache[K, V]) Range(fn func(K, V) bool) {
-	for k, item := range l.data {
-		if !fn(k, item.GetValue()) {
-			break
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for bucket := l.buckets.Front(); bucket != nil; bucket = bucket.Next() {
+		for elem := bucket.Value.(*frequencyBucket[K, V]).entries.Front(); elem != nil; elem = elem.Next() {
+			e := elem.Value.(*lfuEntry[K, V])
+			if l.expiry.expired(e.key) {
+				continue
+			}
+			if !fn(e.key, e.value) {
+				return
+			}
 		}
 	}
 }
+
+func (l *lfuCache[K, V]) Weight() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.weight
+}
+
+// Stop terminates the background cleaner started by WithExpiration
+func (l *lfuCache[K, V]) Stop() {
+	l.expiry.stop()
+}
+
+func newFrequencyBucket[K comparable, V any](frequency int) *frequencyBucket[K, V] {
+	return &frequencyBucket[K, V]{frequency: frequency, entries: list.New()}
+}
+
+func (l *lfuCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return l.events.Subscribe(callback)
+}
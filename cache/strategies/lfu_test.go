@@ -1,6 +1,8 @@
 package strategies_test
 
 import (
+	"fmt"
+	"github.com/kimvlry/caching/cache"
 	"github.com/kimvlry/caching/cache/strategies"
 	"testing"
 
@@ -51,3 +53,74 @@ func TestLFUCache(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 4, val)
 }
+
+// TestLFUCacheTieBreak tests that among entries used equally often the least recently used one is evicted
+func TestLFUCacheTieBreak(t *testing.T) {
+	c := strategies.NewLfuCache[string, int](3)()
+
+	_ = c.Set("a", 1)
+	_ = c.Set("b", 2)
+	_ = c.Set("c", 3)
+	_, _ = c.Get("b")
+	_, _ = c.Get("a")
+	_, _ = c.Get("c")
+
+	// All entries were used twice; "b" was used least recently
+	require.NoError(t, c.Set("d", 4))
+	_, err := c.Get("b")
+	assert.Error(t, err)
+
+	var keys []string
+	c.Range(func(key string, _ int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assert.Equal(t, []string{"d", "a", "c"}, keys)
+}
+
+// BenchmarkLFUCache measures Get and Set on a full cache
+func BenchmarkLFUCache(b *testing.B) {
+	c := strategies.NewLfuCache[int, int](1024)()
+	for i := 0; i < 1024; i++ {
+		_ = c.Set(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%4 == 0 {
+			_ = c.Set(i%2048, i)
+		} else {
+			_, _ = c.Get(i % 1024)
+		}
+	}
+}
+
+// TestLFUCacheAging tests that a formerly hot key is evicted once the workload moves on, but only with aging enabled
+func TestLFUCacheAging(t *testing.T) {
+	hotKeyEvicted := func(opts ...strategies.Option[string, int]) bool {
+		c := strategies.NewLfuCache[string, int](2, opts...)()
+		evicted := false
+		c.(cache.ObservableCache[string, int]).OnEvent(func(event cache.Event[string, int]) {
+			if event.Type == cache.EventTypeEviction && event.Key == "hot" {
+				evicted = true
+			}
+		})
+
+		_ = c.Set("hot", 0)
+		for i := 0; i < 100; i++ {
+			_, _ = c.Get("hot")
+		}
+
+		// The workload moves on: each new key is used a few times and then replaced by the next one
+		for i := 0; i < 100 && !evicted; i++ {
+			key := fmt.Sprintf("key-%d", i)
+			_ = c.Set(key, i)
+			for j := 0; j < 3; j++ {
+				_, _ = c.Get(key)
+			}
+		}
+		return evicted
+	}
+
+	assert.False(t, hotKeyEvicted(), "without aging the hot key should stay forever")
+	assert.True(t, hotKeyEvicted(strategies.WithAging[string, int](8)))
+}
@@ -0,0 +1,400 @@
+package strategies
+
+import (
+	"container/list"
+	"errors"
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+)
+
+type lirsStatus uint8
+
+const (
+	statusLir lirsStatus = iota
+	statusHirResident
+	statusHirNonResident
+)
+
+// lirsCache implements Low Inter-reference Recency Set replacement.
+// Entries reused at short distances form the LIR set, which takes most of the capacity and is never evicted directly.
+// The remaining HIR share holds the other resident entries in a FIFO queue, and the eviction victim is its oldest one.
+// The stack orders entries by recency, non-resident HIR keys included: an entry reused while it is still in the
+// stack has a shorter reuse distance than the least recent LIR entry, which it replaces in the LIR set.
+// Loops slightly larger than the cache therefore keep most of their entries cached, where LRU keeps none.
+type lirsCache[K comparable, V any] struct {
+	capacity int
+	lirCap   int64
+	weigher  weigher[K, V]
+
+	data        map[K]*lirsEntry[K, V]
+	stack       *list.List // *lirsEntry, most recent at the front, a LIR entry at the back
+	queue       *list.List // resident HIR *lirsEntry, newest at the front
+	nonResident *list.List // non-resident HIR *lirsEntry kept in the stack, newest at the front
+	mu          sync.Locker
+
+	lirWeight, queueWeight, nonResidentWeight int64
+
+	events *cache.EventDispatcher[K, V]
+}
+
+type lirsEntry[K comparable, V any] struct {
+	key    K
+	value  V
+	weight int64
+	status lirsStatus
+
+	inStack, inQueue, inNonResident *list.Element
+}
+
+func newLirsCache[K comparable, V any](capacity int, hirRatio float64, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	capacity = max(capacity, 1)
+	// The HIR share gets a slot at least, unless the capacity is a single slot and LIRS evicts like LRU
+	hirCap := min(max(int(float64(capacity)*hirRatio), 1), capacity-1)
+	cfg := newConfig(opts)
+	l := &lirsCache[K, V]{
+		capacity: capacity,
+		lirCap:   int64(capacity - hirCap),
+		weigher:  cfg.weigher,
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
+	}
+	l.reset()
+	return l
+}
+
+func (l *lirsCache[K, V]) reset() {
+	l.data = make(map[K]*lirsEntry[K, V], l.weigher.sizeHint(l.capacity))
+	l.stack = list.New()
+	l.queue = list.New()
+	l.nonResident = list.New()
+	l.lirWeight, l.queueWeight, l.nonResidentWeight = 0, 0, 0
+}
+
+func (l *lirsCache[K, V]) Get(key K) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.get(key)
+}
+
+func (l *lirsCache[K, V]) get(key K) (V, error) {
+	if e, exists := l.data[key]; exists && e.status != statusHirNonResident {
+		l.access(e)
+		l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: e.value})
+		return e.value, nil
+	}
+
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+	var zero V
+	return zero, common.ErrKeyNotFound
+}
+
+func (l *lirsCache[K, V]) Set(key K, value V) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.set(key, value)
+}
+
+func (l *lirsCache[K, V]) set(key K, value V) error {
+	weight, err := l.weigher.admit(key, value, l.capacity)
+	if err != nil {
+		return err
+	}
+	e, exists := l.data[key]
+	if exists && e.status != statusHirNonResident {
+		if e.status == statusLir {
+			l.lirWeight += weight - e.weight
+		} else {
+			l.queueWeight += weight - e.weight
+		}
+		e.value, e.weight = value, weight
+		l.access(e)
+		l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+		// The new value may weigh more than the old one
+		l.shrinkLir()
+		l.evict(0)
+		return nil
+	}
+
+	l.evict(weight)
+	// Evicting may have dropped the oldest non-resident key, which can be this one
+	e, exists = l.data[key]
+	switch {
+	case l.lirWeight+weight <= l.lirCap:
+		// The LIR set is not full yet, every new entry joins it
+		if !exists {
+			e = &lirsEntry[K, V]{key: key}
+			l.data[key] = e
+		}
+		l.forgetNonResident(e)
+		e.value, e.weight = value, weight
+		e.status = statusLir
+		l.lirWeight += weight
+		l.pushStack(e)
+	case exists:
+		// A non-resident key still in the stack was reused sooner than the least recent LIR entry
+		l.forgetNonResident(e)
+		e.value, e.weight = value, weight
+		e.status = statusLir
+		l.lirWeight += weight
+		l.pushStack(e)
+		l.shrinkLir()
+	default:
+		e = &lirsEntry[K, V]{key: key, value: value, weight: weight, status: statusHirResident}
+		l.data[key] = e
+		l.pushHir(e)
+		e.inQueue = l.queue.PushFront(e)
+		l.queueWeight += weight
+	}
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+	return nil
+}
+
+// access records a hit on a resident entry
+func (l *lirsCache[K, V]) access(e *lirsEntry[K, V]) {
+	switch {
+	case e.status == statusLir:
+		l.pushStack(e)
+		l.prune()
+	case e.inStack != nil || l.lirWeight+e.weight <= l.lirCap:
+		// Reused within the stack, or the LIR set has room: the entry becomes LIR,
+		// and if it did not fit the least recent LIR entry takes its place in the queue
+		l.queue.Remove(e.inQueue)
+		e.inQueue = nil
+		e.status = statusLir
+		l.queueWeight -= e.weight
+		l.lirWeight += e.weight
+		l.pushStack(e)
+		l.shrinkLir()
+	default:
+		l.pushHir(e)
+		l.queue.MoveToFront(e.inQueue)
+	}
+}
+
+// pushHir moves a resident HIR entry to the top of the stack.
+// The bottom of the stack must stay a LIR entry, so without one the entry is only kept in the queue.
+func (l *lirsCache[K, V]) pushHir(e *lirsEntry[K, V]) {
+	if l.stack.Len() > 0 {
+		l.pushStack(e)
+	}
+}
+
+// pushStack moves e to the top of the stack
+func (l *lirsCache[K, V]) pushStack(e *lirsEntry[K, V]) {
+	if e.inStack != nil {
+		l.stack.MoveToFront(e.inStack)
+	} else {
+		e.inStack = l.stack.PushFront(e)
+	}
+}
+
+// shrinkLir demotes the least recent LIR entries until the LIR set fits into its share
+func (l *lirsCache[K, V]) shrinkLir() {
+	for l.lirWeight > l.lirCap {
+		l.demoteBottom()
+	}
+}
+
+// demoteBottom turns the least recent LIR entry into a resident HIR entry
+func (l *lirsCache[K, V]) demoteBottom() {
+	l.prune()
+	elem := l.stack.Back()
+	if elem == nil {
+		return
+	}
+	bottom := elem.Value.(*lirsEntry[K, V])
+	l.stack.Remove(bottom.inStack)
+	bottom.inStack = nil
+	bottom.status = statusHirResident
+	bottom.inQueue = l.queue.PushFront(bottom)
+	l.lirWeight -= bottom.weight
+	l.queueWeight += bottom.weight
+	l.prune()
+}
+
+// prune removes HIR entries from the bottom of the stack until a LIR entry is there,
+// dropping the non-resident ones altogether
+func (l *lirsCache[K, V]) prune() {
+	for elem := l.stack.Back(); elem != nil; elem = l.stack.Back() {
+		e := elem.Value.(*lirsEntry[K, V])
+		if e.status == statusLir {
+			return
+		}
+		l.stack.Remove(elem)
+		e.inStack = nil
+		if e.status == statusHirNonResident {
+			l.forgetNonResident(e)
+			delete(l.data, e.key)
+		}
+	}
+}
+
+// evict drops the oldest resident HIR entries until weight more fits into the capacity
+func (l *lirsCache[K, V]) evict(weight int64) {
+	for l.resident()+weight > int64(l.capacity) && len(l.data) > 0 {
+		if l.queue.Len() == 0 {
+			// Only a weigher or a single slot lets the LIR set alone fill the capacity
+			l.demoteBottom()
+		}
+		l.evictOldest()
+	}
+}
+
+// evictOldest drops the oldest resident HIR entry, keeping its key as non-resident while it is in the stack
+func (l *lirsCache[K, V]) evictOldest() {
+	e := l.queue.Remove(l.queue.Back()).(*lirsEntry[K, V])
+	e.inQueue = nil
+	l.queueWeight -= e.weight
+	value := e.value
+
+	if e.inStack != nil {
+		var zero V
+		e.value = zero
+		e.status = statusHirNonResident
+		e.inNonResident = l.nonResident.PushFront(e)
+		l.nonResidentWeight += e.weight
+		// Bound the metadata: the stack keeps at most capacity non-resident keys, or their weight with a weigher
+		for l.nonResidentWeight > int64(l.capacity) {
+			oldest := l.nonResident.Back().Value.(*lirsEntry[K, V])
+			l.forgetNonResident(oldest)
+			l.stack.Remove(oldest.inStack)
+			oldest.inStack = nil
+			delete(l.data, oldest.key)
+		}
+	} else {
+		delete(l.data, e.key)
+	}
+
+	l.events.Emit(cache.Event[K, V]{
+		Type:   cache.EventTypeEviction,
+		Key:    e.key,
+		Value:  value,
+		Reason: cache.EvictionReasonCapacity,
+	})
+}
+
+// fillLir promotes the most recent resident HIR entries while they fit into the LIR share.
+// Entries that left the stack go below the ones still in it, so the bottom of the stack is a LIR entry again.
+func (l *lirsCache[K, V]) fillLir() {
+	for elem := l.queue.Front(); elem != nil; elem = l.queue.Front() {
+		e := elem.Value.(*lirsEntry[K, V])
+		if l.lirWeight+e.weight > l.lirCap {
+			return
+		}
+		l.queue.Remove(elem)
+		e.inQueue = nil
+		e.status = statusLir
+		l.queueWeight -= e.weight
+		l.lirWeight += e.weight
+		if e.inStack == nil {
+			e.inStack = l.stack.PushBack(e)
+		}
+	}
+}
+
+func (l *lirsCache[K, V]) forgetNonResident(e *lirsEntry[K, V]) {
+	if e.inNonResident != nil {
+		l.nonResident.Remove(e.inNonResident)
+		e.inNonResident = nil
+		l.nonResidentWeight -= e.weight
+	}
+}
+
+func (l *lirsCache[K, V]) resident() int64 {
+	return l.lirWeight + l.queueWeight
+}
+
+func (l *lirsCache[K, V]) Delete(key K) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.delete(key)
+}
+
+func (l *lirsCache[K, V]) delete(key K) error {
+	e, exists := l.data[key]
+	if !exists || e.status == statusHirNonResident {
+		return common.ErrKeyNotFound
+	}
+
+	if e.inStack != nil {
+		l.stack.Remove(e.inStack)
+	}
+	if e.inQueue != nil {
+		l.queue.Remove(e.inQueue)
+		l.queueWeight -= e.weight
+	}
+	if e.status == statusLir {
+		l.lirWeight -= e.weight
+	}
+	delete(l.data, key)
+	l.prune()
+	l.fillLir()
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: e.value})
+	return nil
+}
+
+func (l *lirsCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return getMany(keys, l.get)
+}
+
+// SetMany stores the entries under a single lock acquisition.
+// Like ARC it adds entries one at a time, since every insertion can move entries between the LIR and HIR sets.
+func (l *lirsCache[K, V]) SetMany(entries map[K]V) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var errs []error
+	for key, value := range entries {
+		if err := l.set(key, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (l *lirsCache[K, V]) DeleteMany(keys []K) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return deleteMany(keys, l.delete)
+}
+
+func (l *lirsCache[K, V]) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.reset()
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits resident entries in the stack from the most recent one, then resident HIR entries that left the stack
+func (l *lirsCache[K, V]) Range(fn func(K, V) bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for elem := l.stack.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*lirsEntry[K, V])
+		if e.status != statusHirNonResident && !fn(e.key, e.value) {
+			return
+		}
+	}
+	for elem := l.queue.Front(); elem != nil; elem = elem.Next() {
+		e := elem.Value.(*lirsEntry[K, V])
+		if e.inStack == nil && !fn(e.key, e.value) {
+			return
+		}
+	}
+}
+
+func (l *lirsCache[K, V]) Weight() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.resident()
+}
+
+func (l *lirsCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return l.events.Subscribe(callback)
+}
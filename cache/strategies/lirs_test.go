@@ -0,0 +1,160 @@
+package strategies_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loopHits replays a loop over keys slightly more numerous than the capacity and counts the hits
+func loopHits(factory strategies.CacheFactory[int, int], keys, rounds int) int {
+	c := factory()
+	count := 0
+	for round := 0; round < rounds; round++ {
+		for key := 0; key < keys; key++ {
+			if _, err := c.Get(key); err == nil {
+				count++
+			} else {
+				_ = c.Set(key, key)
+			}
+		}
+	}
+	return count
+}
+
+// TestLirsCacheLoop tests that LIRS keeps most of a loop slightly larger than the cache, where LRU and ARC thrash
+func TestLirsCacheLoop(t *testing.T) {
+	const (
+		capacity = 100
+		keys     = 110
+		rounds   = 20
+	)
+
+	lru := loopHits(strategies.NewLruCache[int, int](capacity), keys, rounds)
+	arc := loopHits(strategies.NewArcCache[int, int](capacity), keys, rounds)
+	lirs := loopHits(strategies.NewLirsCache[int, int](capacity, 0.01), keys, rounds)
+
+	assert.Zero(t, lru)
+	assert.Zero(t, arc)
+	// Every round after the first, the 99 LIR entries hit
+	assert.GreaterOrEqual(t, lirs, (rounds-1)*(capacity-2))
+}
+
+// TestLirsCache tests basic operations and that an entry reused within the stack joins the LIR set
+func TestLirsCache(t *testing.T) {
+	c := strategies.NewLirsCache[string, int](3, 0.34)()
+	events := recordEvents(t, c)
+
+	// "a" and "b" fill the LIR set, "c" is a resident HIR entry
+	require.NoError(t, c.Set("a", 1))
+	require.NoError(t, c.Set("b", 2))
+	require.NoError(t, c.Set("c", 3))
+
+	// "d" evicts "c", the only resident HIR entry, which stays in the stack as a non-resident key
+	require.NoError(t, c.Set("d", 4))
+	_, err := c.Get("c")
+	assert.Error(t, err)
+
+	// "c" comes back while still in the stack: it becomes LIR and "a", the least recent LIR entry, becomes HIR
+	require.NoError(t, c.Set("c", 30))
+	require.NoError(t, c.Set("e", 5))
+	_, err = c.Get("a")
+	assert.Error(t, err, "a should have been demoted and then evicted")
+	for key, want := range map[string]int{"b": 2, "c": 30, "e": 5} {
+		val, err := c.Get(key)
+		require.NoError(t, err, key)
+		assert.Equal(t, want, val)
+	}
+
+	var evicted []string
+	for _, event := range *events {
+		if event.Type == cache.EventTypeEviction {
+			evicted = append(evicted, event.Key)
+		}
+	}
+	assert.Equal(t, []string{"c", "d", "a"}, evicted)
+}
+
+// TestLirsCacheRandomTrace tests that LIRS never outgrows its capacity under a mixed workload
+func TestLirsCacheRandomTrace(t *testing.T) {
+	c := strategies.NewLirsCache[int, int](16, 0.25)()
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 20_000; i++ {
+		key := rng.Intn(48)
+		switch rng.Intn(10) {
+		case 0:
+			_ = c.Delete(key)
+		case 1, 2, 3, 4:
+			_ = c.Set(key, i)
+		default:
+			if val, err := c.Get(key); err == nil {
+				require.GreaterOrEqual(t, val, 0)
+			}
+		}
+
+		size := 0
+		c.Range(func(int, int) bool {
+			size++
+			return true
+		})
+		require.LessOrEqual(t, size, 16)
+	}
+}
+
+// TestLirsCacheDeleteLir tests that deleting the whole LIR set leaves resident entries intact
+func TestLirsCacheDeleteLir(t *testing.T) {
+	c := strategies.NewLirsCache[string, int](3, 0.3)()
+	events := recordEvents(t, c)
+	for _, op := range []struct {
+		op, key string
+		value   int
+	}{
+		{"set", "1", 2}, {"set", "2", 4}, {"del", "1", 0}, {"set", "3", 6}, {"get", "2", 0}, {"set", "1", 9},
+		{"set", "4", 11}, {"set", "4", 13}, {"get", "1", 0}, {"del", "2", 0}, {"del", "4", 0}, {"get", "3", 0},
+		{"set", "4", 21}, {"set", "0", 22}, {"set", "2", 23}, {"set", "2", 24},
+	} {
+		switch op.op {
+		case "set":
+			require.NoError(t, c.Set(op.key, op.value))
+		case "del":
+			require.NoError(t, c.Delete(op.key))
+		default:
+			_, _ = c.Get(op.key)
+		}
+	}
+
+	want := map[string]int{}
+	for _, event := range *events {
+		switch event.Type {
+		case cache.EventTypeInsert, cache.EventTypeUpdate:
+			want[event.Key] = event.Value
+		case cache.EventTypeEviction, cache.EventTypeDelete:
+			delete(want, event.Key)
+		}
+	}
+	got := map[string]int{}
+	c.Range(func(key string, value int) bool {
+		got[key] = value
+		return true
+	})
+	assert.Equal(t, want, got)
+	assert.Len(t, got, 3)
+	for key, value := range want {
+		val, err := c.Get(key)
+		require.NoError(t, err, key)
+		assert.Equal(t, value, val)
+	}
+}
+
+// TestLirsCacheSingleSlot tests that a capacity below two is honored
+func TestLirsCacheSingleSlot(t *testing.T) {
+	c := strategies.NewLirsCache[string, int](1, 0.25)()
+	for i, key := range []string{"a", "b", "a", "c", "c", "b"} {
+		require.NoError(t, c.Set(key, i))
+		assert.Equal(t, []string{key}, keysOf(c))
+	}
+}
@@ -4,91 +4,258 @@ import (
 	"container/list"
 	"github.com/kimvlry/caching/cache"
 	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+	"time"
 )
 
 // lruCache implements a Least Recently Used cache
 type lruCache[K comparable, V any] struct {
 	capacity int
+	weight   int64
+	weigher  weigher[K, V]
 	data     map[K]*list.Element
 	keys     *list.List
+	expiry   *expiry[K, V]
+	mu       sync.Locker
 
-	eventCallbacks []func(cache.Event[K, V])
+	events *cache.EventDispatcher[K, V]
 }
 
-func newLruCache[K comparable, V any](capacity int) cache.IterableCache[K, V] {
-	return &lruCache[K, V]{
+func newLruCache[K comparable, V any](capacity int, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	cfg := newConfig(opts)
+	l := &lruCache[K, V]{
 		capacity: capacity,
-		data:     make(map[K]*list.Element, capacity),
+		weigher:  cfg.weigher,
+		data:     make(map[K]*list.Element, cfg.weigher.sizeHint(capacity)),
 		keys:     list.New(),
+		expiry:   newExpiry(cfg),
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
 	}
+	l.expiry.startCleaner(cfg, l.purgeExpired)
+	return l
 }
 
 func (l *lruCache[K, V]) Get(key K) (V, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.get(key)
+}
+
+func (l *lruCache[K, V]) get(key K) (V, error) {
+	if l.expiry.expired(key) {
+		l.expire(key)
+	}
 	if element, exists := l.data[key]; exists {
 		l.keys.MoveToBack(element)
-		return element.Value.(*entry[K, V]).value, nil
+		value := element.Value.(*entry[K, V]).value
+		l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: value})
+		return value, nil
 	}
 
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
 	var zero V
 	return zero, common.ErrKeyNotFound
 }
 
 func (l *lruCache[K, V]) Set(key K, value V) error {
-	if elem, exists := l.data[key]; exists {
-		elem.Value.(*entry[K, V]).value = value
-		l.keys.MoveToBack(elem)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.set(key, value); err != nil {
+		return err
+	}
+	l.expiry.trackDefault(key)
+	return nil
+}
+
+// SetWithTTL stores the value and lets it expire after ttl, whatever its recency
+func (l *lruCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.set(key, value); err != nil {
+		return err
+	}
+	l.expiry.track(key, ttl)
+	return nil
+}
+
+func (l *lruCache[K, V]) GetDefaultTTL() time.Duration {
+	return l.expiry.defaultTTL
+}
+
+func (l *lruCache[K, V]) set(key K, value V) error {
+	weight, err := l.weigher.admit(key, value, l.capacity)
+	if err != nil {
+		return err
+	}
+	if l.update(key, value, weight) {
+		// The new value may weigh more than the old one
+		l.evict(0)
 		return nil
 	}
+	l.evict(weight)
+	l.insert(key, value, weight)
+	return nil
+}
 
-	if len(l.data) >= l.capacity {
-		oldest := l.keys.Front()
-		if oldest != nil {
-			evicted := oldest.Value.(*entry[K, V])
-			delete(l.data, evicted.key)
-			l.keys.Remove(oldest)
-			l.emit(cache.Event[K, V]{
-				Type:  cache.EventTypeEviction,
-				Key:   evicted.key,
-				Value: evicted.value,
-			})
-		}
+// update overwrites the value of a present key and marks it as most recently used
+func (l *lruCache[K, V]) update(key K, value V, weight int64) bool {
+	elem, exists := l.data[key]
+	if !exists {
+		return false
 	}
+	e := elem.Value.(*entry[K, V])
+	l.weight += weight - e.weight
+	e.value, e.weight = value, weight
+	l.keys.MoveToBack(elem)
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+	return true
+}
 
-	e := &entry[K, V]{key: key, value: value}
+func (l *lruCache[K, V]) insert(key K, value V, weight int64) {
+	e := &entry[K, V]{key: key, value: value, weight: weight}
 	elem := l.keys.PushBack(e)
 	l.data[key] = elem
-	return nil
+	l.weight += weight
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+}
+
+// evict removes least recently used entries until weight more fits into the capacity
+func (l *lruCache[K, V]) evict(weight int64) {
+	for l.weight+weight > int64(l.capacity) {
+		oldest := l.keys.Front()
+		if oldest == nil {
+			return
+		}
+		evicted := oldest.Value.(*entry[K, V])
+		l.remove(evicted.key)
+		l.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    evicted.key,
+			Value:  evicted.value,
+			Reason: cache.EvictionReasonCapacity,
+		})
+	}
+}
+
+// expire drops an entry whose deadline has passed
+func (l *lruCache[K, V]) expire(key K) {
+	if value, removed := l.remove(key); removed {
+		l.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    key,
+			Value:  value,
+			Reason: cache.EvictionReasonExpired,
+		})
+	}
+}
+
+// purgeExpired is called by the background cleaner
+func (l *lruCache[K, V]) purgeExpired() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range l.expiry.popExpired() {
+		l.expire(key)
+	}
+}
+
+// remove unlinks an entry without emitting an event
+func (l *lruCache[K, V]) remove(key K) (V, bool) {
+	elem, exists := l.data[key]
+	if !exists {
+		var zero V
+		return zero, false
+	}
+	l.keys.Remove(elem)
+	delete(l.data, key)
+	l.expiry.forget(key)
+	e := elem.Value.(*entry[K, V])
+	l.weight -= e.weight
+	return e.value, true
 }
 
 func (l *lruCache[K, V]) Delete(key K) error {
-	if elem, exists := l.data[key]; exists {
-		l.keys.Remove(elem)
-		delete(l.data, key)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.delete(key)
+}
+
+func (l *lruCache[K, V]) delete(key K) error {
+	if value, removed := l.remove(key); removed {
+		l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: value})
 		return nil
 	}
 	return common.ErrKeyNotFound
 }
 
+func (l *lruCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return getMany(keys, l.get)
+}
+
+// SetMany updates present keys, then makes room for all new keys in a single eviction pass.
+// If the new keys outweigh the capacity, only as many of them as fit are stored.
+func (l *lruCache[K, V]) SetMany(entries map[K]V) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	weights, err := weighMany(entries, l.weigher, l.capacity)
+	fresh, weight := fitting(freshKeys(entries, weights, l.update), weights, l.capacity)
+	l.evict(weight)
+	for _, key := range fresh {
+		l.insert(key, entries[key], weights[key])
+	}
+	trackStored(l.expiry, weights, l.data)
+	return err
+}
+
+func (l *lruCache[K, V]) DeleteMany(keys []K) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return deleteMany(keys, l.delete)
+}
+
 func (l *lruCache[K, V]) Clear() {
-	l.data = make(map[K]*list.Element, l.capacity)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.data = make(map[K]*list.Element, l.weigher.sizeHint(l.capacity))
 	l.keys = list.New()
+	l.weight = 0
+	l.expiry.reset()
+	l.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
 }
 
 func (l *lruCache[K, V]) Range(fn func(K, V) bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	for elem := l.keys.Front(); elem != nil; elem = elem.Next() {
 		e := elem.Value.(*entry[K, V])
+		if l.expiry.expired(e.key) {
+			continue
+		}
 		if !fn(e.key, e.value) {
 			break
 		}
 	}
 }
 
-func (l *lruCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) {
-	l.eventCallbacks = append(l.eventCallbacks, callback)
+func (l *lruCache[K, V]) Weight() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.weight
 }
 
-func (l *lruCache[K, V]) emit(event cache.Event[K, V]) {
-	for _, callback := range l.eventCallbacks {
-		callback(event)
-	}
+// Stop terminates the background cleaner started by WithExpiration
+func (l *lruCache[K, V]) Stop() {
+	l.expiry.stop()
+}
+
+func (l *lruCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return l.events.Subscribe(callback)
 }
@@ -0,0 +1,203 @@
+package strategies
+
+import (
+	"context"
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/clock"
+	"sync"
+	"time"
+)
+
+// Option configures optional behaviour of the caches built by the factories in this package
+type Option[K comparable, V any] func(*config[K, V])
+
+type config[K comparable, V any] struct {
+	concurrent bool
+	events     *cache.EventDispatcher[K, V]
+
+	defaultTTL      time.Duration
+	cleanupInterval time.Duration
+
+	agingPeriod int
+
+	weigher weigher[K, V]
+
+	sliding     bool
+	maxLifetime time.Duration
+
+	wheelResolution time.Duration
+
+	refresh *RefreshPolicy[K, V]
+
+	earlyExpirationBeta float64
+	ttlJitter           float64
+
+	clock clock.Clock
+}
+
+func newConfig[K comparable, V any](opts []Option[K, V]) *config[K, V] {
+	cfg := &config[K, V]{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithConcurrency makes the cache safe for concurrent use by multiple goroutines.
+// Every method, including Range, is serialized by a single mutex held by the cache.
+// Range callbacks, and event listeners of a synchronous dispatcher, run while that mutex is held,
+// so they must not call back into the same cache.
+func WithConcurrency[K comparable, V any]() Option[K, V] {
+	return func(c *config[K, V]) {
+		c.concurrent = true
+	}
+}
+
+// WithEventDispatcher makes the cache emit its events through dispatcher instead of a private synchronous one.
+// Use it with cache.NewAsyncEventDispatcher to move listeners off the cache's hot path, or to share listeners
+// between several caches.
+func WithEventDispatcher[K comparable, V any](dispatcher *cache.EventDispatcher[K, V]) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.events = dispatcher
+	}
+}
+
+// WithExpiration lets entries of the LRU, LFU, FIFO, ARC and sampled caches expire on top of their eviction policy.
+// Set gives entries defaultTTL (zero means they never expire) and SetWithTTL sets a ttl per entry.
+// Expired entries are dropped lazily by Get and skipped by Range; a positive cleanupInterval also starts a background
+// cleaner, which makes the cache synchronized and must be stopped with Stop once the cache is no longer used.
+// The TTL cache takes its ttl from NewTtlCache and ignores this option.
+func WithExpiration[K comparable, V any](defaultTTL, cleanupInterval time.Duration) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.defaultTTL = defaultTTL
+		c.cleanupInterval = cleanupInterval
+	}
+}
+
+// WithAging makes the LFU cache halve the use count of every entry after each period uses
+// (hits, writes and inserts), so entries that were popular long ago stop shielding themselves
+// from eviction and the cache follows shifting workloads. Halving costs time linear in the cache size,
+// amortized over period uses. Other strategies ignore this option.
+func WithAging[K comparable, V any](period int) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.agingPeriod = period
+	}
+}
+
+// WithWeigher turns the capacity of the cache into a budget of weight, such as bytes or cost, instead of a number
+// of entries. Storing an entry evicts others, following the strategy's policy, until the new one fits, and an entry
+// weighing more than the whole capacity is rejected with a *common.EntryTooLargeError. Segments and ghost histories
+// sized from the capacity are weighed too. Negative weights count as zero, and weigher must return the same weight
+// for the same entry every time. WeightedCache reports the current total weight.
+func WithWeigher[K comparable, V any](weigher func(key K, value V) int64) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.weigher = weigher
+	}
+}
+
+// WithSlidingExpiration makes the TTL cache expire entries after a period without access instead of a period after
+// they were written: every successful Get restarts the ttl of its entry. A positive maxLifetime caps how long an entry
+// can be kept alive that way, counted from its last write. Other strategies ignore this option.
+func WithSlidingExpiration[K comparable, V any](maxLifetime time.Duration) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.sliding = true
+		c.maxLifetime = maxLifetime
+	}
+}
+
+// WithTimingWheel makes the TTL cache index deadlines in a hierarchical timing wheel ticking at resolution instead of
+// a heap. Storing or touching an entry becomes O(1), and the background evictor runs every resolution rather than every
+// half default ttl, so expired entries are dropped within about two resolutions whatever their ttl. Capacity evictions
+// still go to the entries closest to their deadline, but only to within the span of a wheel slot.
+// A resolution below a millisecond is raised to a millisecond. Other strategies ignore this option.
+func WithTimingWheel[K comparable, V any](resolution time.Duration) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.wheelResolution = max(resolution, time.Millisecond)
+	}
+}
+
+// RefreshPolicy makes the TTL cache reload entries in the background instead of letting hot keys expire.
+// Reloads are started by Get, one at a time per key, and emit cache.EventTypeRefresh or cache.EventTypeRefreshFailure.
+// A Set while a reload runs wins over its result.
+type RefreshPolicy[K comparable, V any] struct {
+	// Loader fetches the current value of a key. It runs on its own goroutine, with a context cancelled by Stop.
+	Loader func(ctx context.Context, key K) (V, error)
+	// RefreshAfter is the fraction of its ttl after which a Get reloads an entry, while still returning the cached
+	// value. Zero, or one and above, only reload entries that have expired, which takes a grace period.
+	RefreshAfter float64
+	// Grace is how long an expired entry is still served by Get, stale, while it is being reloaded
+	Grace time.Duration
+	// StaleIfError keeps serving an expired entry until the end of its grace period when its reload fails.
+	// Otherwise the failure drops it, and the next Get misses.
+	StaleIfError bool
+}
+
+// WithRefresh gives the TTL cache a refresh policy. Other strategies ignore this option.
+func WithRefresh[K comparable, V any](policy RefreshPolicy[K, V]) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.refresh = &policy
+	}
+}
+
+// WithEarlyExpiration makes Get on the TTL cache miss on some entries before they expire, following XFetch
+// (Vattani, Chierichetti and Lowenstein, "Optimal Probabilistic Cache Stampede Prevention"): a Get treats an entry as
+// expired when now - recompute·beta·ln(rand()) reaches its deadline, where recompute is how long the value took to
+// compute. The closer an entry is to its deadline and the longer it takes to recompute, the more likely a caller gets
+// to recompute it early, one caller at a time rather than all of them when it expires. An early miss leaves the entry
+// in place. Recompute times come from EarlyExpiringCache.SetWithRecomputeTime and from reloads of a refresh policy;
+// entries without one never expire early. A beta of 1 is the usual choice, larger ones recompute earlier and
+// non-positive ones mean 1. Other strategies ignore this option.
+func WithEarlyExpiration[K comparable, V any](beta float64) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.earlyExpirationBeta = 1
+		if beta > 0 {
+			c.earlyExpirationBeta = beta
+		}
+	}
+}
+
+// WithTTLJitter spreads the ttl of every write randomly within fraction of it either way, e.g. 0.1 for ±10%,
+// so entries written together do not expire together. It applies to every strategy with expiration.
+func WithTTLJitter[K comparable, V any](fraction float64) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.ttlJitter = min(max(fraction, 0), 1)
+	}
+}
+
+// WithClock makes the cache read the time, and run its background cleaner, on clk instead of the wall clock.
+// Tests can pass a clocktest.FakeClock to expire entries without sleeping.
+func WithClock[K comparable, V any](clk clock.Clock) Option[K, V] {
+	return func(c *config[K, V]) {
+		c.clock = clk
+	}
+}
+
+// dispatcher returns the dispatcher events of a cache built with this config go through
+func (c *config[K, V]) dispatcher() *cache.EventDispatcher[K, V] {
+	if c.events != nil {
+		return c.events
+	}
+	return cache.NewEventDispatcher[K, V]()
+}
+
+// timeSource returns the clock of a cache built with this config
+func (c *config[K, V]) timeSource() clock.Clock {
+	if c.clock != nil {
+		return c.clock
+	}
+	return clock.Real()
+}
+
+// locker returns the lock guarding a cache built with this config
+func (c *config[K, V]) locker() sync.Locker {
+	if c.concurrent || c.cleanupInterval > 0 {
+		return &sync.Mutex{}
+	}
+	return noopLocker{}
+}
+
+// noopLocker is used by caches that were not asked to be safe for concurrent use
+type noopLocker struct{}
+
+func (noopLocker) Lock()   {}
+func (noopLocker) Unlock() {}
@@ -9,8 +9,13 @@ type ttlHeapItem[K comparable, V any] struct {
 	index     int
 }
 
+// NewTTLHeapItem returns an item expiring ttl from now, as told by the wall clock
 func NewTTLHeapItem[K comparable, V any](key K, value V, ttl time.Duration) Item[K, V] {
-	expiresAt := time.Now().Add(ttl)
+	return NewTTLHeapItemAt(key, value, time.Now().Add(ttl))
+}
+
+// NewTTLHeapItemAt returns an item expiring at expiresAt, for callers reading the time from their own clock
+func NewTTLHeapItemAt[K comparable, V any](key K, value V, expiresAt time.Time) Item[K, V] {
 	return &ttlHeapItem[K, V]{
 		Key:       key,
 		Value:     value,
@@ -0,0 +1,299 @@
+package strategies
+
+import (
+	"container/list"
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+)
+
+const (
+	s3FifoSmallPercent = 10 // share of the capacity given to the small queue
+	s3FifoMaxFrequency = 3
+)
+
+// s3FifoCache implements S3-FIFO.
+// New entries go to a small FIFO queue; those hit more than once before they reach its end move to the main queue,
+// the others are evicted early (quick demotion) and remembered in a ghost queue, so their next insertion goes
+// straight to the main queue. The main queue reinserts entries that were hit since their last pass (lazy promotion).
+// Hits only bump a small counter, so Get never moves list nodes.
+type s3FifoCache[K comparable, V any] struct {
+	capacity int
+	smallCap int64
+	ghostCap int64
+	weigher  weigher[K, V]
+
+	weight      int64 // of the small and main queues
+	smallWeight int64
+	ghostWeight int64
+
+	data   map[K]*list.Element
+	small  *list.List // *s3FifoEntry, newest at the front
+	main   *list.List
+	ghost  *list.List // *ghostEntry, newest at the front
+	ghosts map[K]*list.Element
+	mu     sync.Locker
+
+	events *cache.EventDispatcher[K, V]
+}
+
+type s3FifoEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	weight    int64
+	frequency int
+	inMain    bool
+}
+
+func newS3FifoCache[K comparable, V any](capacity int, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	capacity = max(capacity, 1)
+	smallCap := max(capacity*s3FifoSmallPercent/100, 1)
+	cfg := newConfig(opts)
+	return &s3FifoCache[K, V]{
+		capacity: capacity,
+		smallCap: int64(smallCap),
+		ghostCap: int64(max(capacity-smallCap, 1)),
+		weigher:  cfg.weigher,
+		data:     make(map[K]*list.Element, cfg.weigher.sizeHint(capacity)),
+		small:    list.New(),
+		main:     list.New(),
+		ghost:    list.New(),
+		ghosts:   make(map[K]*list.Element),
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
+	}
+}
+
+func (s *s3FifoCache[K, V]) Get(key K) (V, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(key)
+}
+
+func (s *s3FifoCache[K, V]) get(key K) (V, error) {
+	if elem, exists := s.data[key]; exists {
+		e := elem.Value.(*s3FifoEntry[K, V])
+		e.frequency = min(e.frequency+1, s3FifoMaxFrequency)
+		s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: e.value})
+		return e.value, nil
+	}
+
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+	var zero V
+	return zero, common.ErrKeyNotFound
+}
+
+func (s *s3FifoCache[K, V]) Set(key K, value V) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	weight, err := s.weigher.admit(key, value, s.capacity)
+	if err != nil {
+		return err
+	}
+	if s.update(key, value, weight) {
+		// The new value may weigh more than the old one
+		s.evict(0)
+		return nil
+	}
+	s.evict(weight)
+	s.insert(key, value, weight)
+	return nil
+}
+
+// update overwrites the value of a present key, counting the write as a hit
+func (s *s3FifoCache[K, V]) update(key K, value V, weight int64) bool {
+	elem, exists := s.data[key]
+	if !exists {
+		return false
+	}
+	e := elem.Value.(*s3FifoEntry[K, V])
+	s.weight += weight - e.weight
+	if !e.inMain {
+		s.smallWeight += weight - e.weight
+	}
+	e.value, e.weight = value, weight
+	e.frequency = min(e.frequency+1, s3FifoMaxFrequency)
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+	return true
+}
+
+// insert puts a new entry in the small queue, or in the main queue if it was evicted from the small queue recently
+func (s *s3FifoCache[K, V]) insert(key K, value V, weight int64) {
+	e := &s3FifoEntry[K, V]{key: key, value: value, weight: weight}
+	if ghost, remembered := s.ghosts[key]; remembered {
+		s.forgetGhost(ghost)
+		e.inMain = true
+		s.data[key] = s.main.PushFront(e)
+	} else {
+		s.data[key] = s.small.PushFront(e)
+		s.smallWeight += weight
+	}
+	s.weight += weight
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+}
+
+// evict removes entries, from the small queue while it holds its share of the capacity,
+// until weight more fits into the capacity
+func (s *s3FifoCache[K, V]) evict(weight int64) {
+	for s.weight+weight > int64(s.capacity) && len(s.data) > 0 {
+		var evicted *s3FifoEntry[K, V]
+		for evicted == nil {
+			if s.smallWeight >= s.smallCap || s.main.Len() == 0 {
+				evicted = s.evictSmall()
+			} else {
+				evicted = s.evictMain()
+			}
+		}
+
+		s.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    evicted.key,
+			Value:  evicted.value,
+			Reason: cache.EvictionReasonCapacity,
+		})
+	}
+}
+
+// evictSmall moves the oldest small queue entry to the main queue if it was hit more than once,
+// otherwise evicts it into the ghost queue and returns it
+func (s *s3FifoCache[K, V]) evictSmall() *s3FifoEntry[K, V] {
+	elem := s.small.Back()
+	e := s.small.Remove(elem).(*s3FifoEntry[K, V])
+	s.smallWeight -= e.weight
+	if e.frequency > 1 {
+		e.frequency = 0
+		e.inMain = true
+		s.data[e.key] = s.main.PushFront(e)
+		return nil
+	}
+
+	delete(s.data, e.key)
+	s.weight -= e.weight
+	s.ghosts[e.key] = s.ghost.PushFront(&ghostEntry[K]{e.key, e.weight})
+	s.ghostWeight += e.weight
+	for s.ghostWeight > s.ghostCap {
+		s.forgetGhost(s.ghost.Back())
+	}
+	return e
+}
+
+func (s *s3FifoCache[K, V]) forgetGhost(elem *list.Element) {
+	ghost := s.ghost.Remove(elem).(*ghostEntry[K])
+	delete(s.ghosts, ghost.key)
+	s.ghostWeight -= ghost.weight
+}
+
+// evictMain reinserts the oldest main queue entry if it was hit since its last pass, otherwise evicts and returns it
+func (s *s3FifoCache[K, V]) evictMain() *s3FifoEntry[K, V] {
+	elem := s.main.Back()
+	e := elem.Value.(*s3FifoEntry[K, V])
+	if e.frequency > 0 {
+		e.frequency--
+		s.main.MoveToFront(elem)
+		return nil
+	}
+
+	s.main.Remove(elem)
+	delete(s.data, e.key)
+	s.weight -= e.weight
+	return e
+}
+
+// remove unlinks an entry without emitting an event
+func (s *s3FifoCache[K, V]) remove(key K) (V, bool) {
+	elem, exists := s.data[key]
+	if !exists {
+		var zero V
+		return zero, false
+	}
+	e := elem.Value.(*s3FifoEntry[K, V])
+	if e.inMain {
+		s.main.Remove(elem)
+	} else {
+		s.small.Remove(elem)
+		s.smallWeight -= e.weight
+	}
+	delete(s.data, key)
+	s.weight -= e.weight
+	return e.value, true
+}
+
+func (s *s3FifoCache[K, V]) Delete(key K) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delete(key)
+}
+
+func (s *s3FifoCache[K, V]) delete(key K) error {
+	if value, removed := s.remove(key); removed {
+		s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: value})
+		return nil
+	}
+	return common.ErrKeyNotFound
+}
+
+func (s *s3FifoCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return getMany(keys, s.get)
+}
+
+// SetMany updates present keys, then makes room for all new keys in a single eviction pass.
+// If the new keys outweigh the capacity, only as many of them as fit are stored.
+func (s *s3FifoCache[K, V]) SetMany(entries map[K]V) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	weights, err := weighMany(entries, s.weigher, s.capacity)
+	fresh, weight := fitting(freshKeys(entries, weights, s.update), weights, s.capacity)
+	s.evict(weight)
+	for _, key := range fresh {
+		s.insert(key, entries[key], weights[key])
+	}
+	return err
+}
+
+func (s *s3FifoCache[K, V]) DeleteMany(keys []K) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return deleteMany(keys, s.delete)
+}
+
+func (s *s3FifoCache[K, V]) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = make(map[K]*list.Element, s.weigher.sizeHint(s.capacity))
+	s.small = list.New()
+	s.main = list.New()
+	s.ghost = list.New()
+	s.ghosts = make(map[K]*list.Element)
+	s.weight, s.smallWeight, s.ghostWeight = 0, 0, 0
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits the small queue, then the main queue, each from the oldest to the newest entry
+func (s *s3FifoCache[K, V]) Range(fn func(K, V) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, queue := range []*list.List{s.small, s.main} {
+		for elem := queue.Back(); elem != nil; elem = elem.Prev() {
+			e := elem.Value.(*s3FifoEntry[K, V])
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+func (s *s3FifoCache[K, V]) Weight() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.weight
+}
+
+func (s *s3FifoCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return s.events.Subscribe(callback)
+}
@@ -0,0 +1,361 @@
+package strategies
+
+import (
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SampledEntry is what a Scorer knows about an entry sampled for eviction
+type SampledEntry[K comparable, V any] struct {
+	Key   K
+	Value V
+	// LastAccess orders entries by their last Get or Set: it is a logical clock, larger means more recent
+	LastAccess uint64
+	// Frequency counts the Gets and Sets of the entry since it was stored
+	Frequency uint64
+	// ExpiresAt is the deadline set by WithExpiration or SetWithTTL, zero if the entry does not expire
+	ExpiresAt time.Time
+}
+
+// Scorer ranks an entry sampled for eviction: of the sampled entries, the one with the lowest score is evicted
+type Scorer[K comparable, V any] func(entry SampledEntry[K, V]) int64
+
+// OldestAccess scores entries by their last access, approximating LRU like Redis's allkeys-lru
+func OldestAccess[K comparable, V any](entry SampledEntry[K, V]) int64 {
+	return int64(entry.LastAccess)
+}
+
+// LowestFrequency scores entries by the number of times they were used, approximating LFU
+func LowestFrequency[K comparable, V any](entry SampledEntry[K, V]) int64 {
+	return int64(entry.Frequency)
+}
+
+// NearestExpiry scores entries by their deadline, like Redis's volatile-ttl; entries that never expire go last
+func NearestExpiry[K comparable, V any](entry SampledEntry[K, V]) int64 {
+	if entry.ExpiresAt.IsZero() {
+		return math.MaxInt64
+	}
+	return entry.ExpiresAt.UnixNano()
+}
+
+// sampledCache approximates a policy by sampling, the way Redis evicts keys: instead of keeping entries ordered,
+// it draws a few random entries whenever it has to evict and evicts the one the scorer ranks lowest.
+// Entries live in a slice, so drawing one at random is O(1), and a removal moves the last entry into the hole.
+// Without a scorer the first drawn entry is evicted, which is random eviction.
+type sampledCache[K comparable, V any] struct {
+	capacity   int
+	sampleSize int
+	score      Scorer[K, V]
+	weight     int64
+	weigher    weigher[K, V]
+
+	entries []sampledEntry[K, V]
+	index   map[K]int
+	clock   uint64
+	rand    *rand.Rand
+	expiry  *expiry[K, V]
+	mu      sync.Locker
+
+	events *cache.EventDispatcher[K, V]
+}
+
+type sampledEntry[K comparable, V any] struct {
+	key        K
+	value      V
+	weight     int64
+	lastAccess uint64
+	frequency  uint64
+}
+
+func newSampledCache[K comparable, V any](
+	capacity, sampleSize int,
+	score Scorer[K, V],
+	opts ...Option[K, V],
+) cache.IterableCache[K, V] {
+	cfg := newConfig(opts)
+	s := &sampledCache[K, V]{
+		capacity:   capacity,
+		sampleSize: max(sampleSize, 1),
+		score:      score,
+		weigher:    cfg.weigher,
+		entries:    make([]sampledEntry[K, V], 0, cfg.weigher.sizeHint(capacity)),
+		index:      make(map[K]int, cfg.weigher.sizeHint(capacity)),
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		expiry:     newExpiry(cfg),
+		mu:         cfg.locker(),
+		events:     cfg.dispatcher(),
+	}
+	s.expiry.startCleaner(cfg, s.purgeExpired)
+	return s
+}
+
+func (s *sampledCache[K, V]) Get(key K) (V, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(key)
+}
+
+func (s *sampledCache[K, V]) get(key K) (V, error) {
+	if s.expiry.expired(key) {
+		s.expire(key)
+	}
+	if i, exists := s.index[key]; exists {
+		e := &s.entries[i]
+		s.touch(e)
+		s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: e.value})
+		return e.value, nil
+	}
+
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+	var zero V
+	return zero, common.ErrKeyNotFound
+}
+
+func (s *sampledCache[K, V]) touch(e *sampledEntry[K, V]) {
+	s.clock++
+	e.lastAccess = s.clock
+	e.frequency++
+}
+
+func (s *sampledCache[K, V]) Set(key K, value V) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.set(key, value); err != nil {
+		return err
+	}
+	s.expiry.trackDefault(key)
+	return nil
+}
+
+// SetWithTTL stores the value and lets it expire after ttl, whether or not it is ever sampled
+func (s *sampledCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.set(key, value); err != nil {
+		return err
+	}
+	s.expiry.track(key, ttl)
+	return nil
+}
+
+func (s *sampledCache[K, V]) GetDefaultTTL() time.Duration {
+	return s.expiry.defaultTTL
+}
+
+func (s *sampledCache[K, V]) set(key K, value V) error {
+	weight, err := s.weigher.admit(key, value, s.capacity)
+	if err != nil {
+		return err
+	}
+	if s.update(key, value, weight) {
+		// The new value may weigh more than the old one
+		s.evict(0)
+		return nil
+	}
+	s.evict(weight)
+	s.insert(key, value, weight)
+	return nil
+}
+
+func (s *sampledCache[K, V]) update(key K, value V, weight int64) bool {
+	i, exists := s.index[key]
+	if !exists {
+		return false
+	}
+	e := &s.entries[i]
+	s.weight += weight - e.weight
+	e.value, e.weight = value, weight
+	s.touch(e)
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+	return true
+}
+
+func (s *sampledCache[K, V]) insert(key K, value V, weight int64) {
+	s.index[key] = len(s.entries)
+	s.entries = append(s.entries, sampledEntry[K, V]{key: key, value: value, weight: weight})
+	s.touch(&s.entries[len(s.entries)-1])
+	s.weight += weight
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+}
+
+// evict removes the lowest scored entry of a fresh sample until weight more fits into the capacity
+func (s *sampledCache[K, V]) evict(weight int64) {
+	for s.weight+weight > int64(s.capacity) && len(s.entries) > 0 {
+		victim := s.entries[s.sample()]
+		s.remove(victim.key)
+		s.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    victim.key,
+			Value:  victim.value,
+			Reason: cache.EvictionReasonCapacity,
+		})
+	}
+}
+
+// sample returns the position of the lowest scored of sampleSize random entries.
+// Entries are drawn with replacement; when the sample would cover the whole cache, every entry is scored instead.
+func (s *sampledCache[K, V]) sample() int {
+	if s.score == nil {
+		return s.rand.Intn(len(s.entries))
+	}
+
+	best, bestScore := -1, int64(0)
+	consider := func(i int) {
+		if score := s.score(s.describe(&s.entries[i])); best < 0 || score < bestScore {
+			best, bestScore = i, score
+		}
+	}
+	if s.sampleSize >= len(s.entries) {
+		for i := range s.entries {
+			consider(i)
+		}
+	} else {
+		for n := 0; n < s.sampleSize; n++ {
+			consider(s.rand.Intn(len(s.entries)))
+		}
+	}
+	return best
+}
+
+func (s *sampledCache[K, V]) describe(e *sampledEntry[K, V]) SampledEntry[K, V] {
+	expiresAt, _ := s.expiry.deadline(e.key)
+	return SampledEntry[K, V]{
+		Key:        e.key,
+		Value:      e.value,
+		LastAccess: e.lastAccess,
+		Frequency:  e.frequency,
+		ExpiresAt:  expiresAt,
+	}
+}
+
+// remove takes an entry out of the slice, moving the last entry into its place
+func (s *sampledCache[K, V]) remove(key K) (V, bool) {
+	i, exists := s.index[key]
+	if !exists {
+		var zero V
+		return zero, false
+	}
+	e := s.entries[i]
+	last := len(s.entries) - 1
+	if i != last {
+		s.entries[i] = s.entries[last]
+		s.index[s.entries[i].key] = i
+	}
+	s.entries[last] = sampledEntry[K, V]{}
+	s.entries = s.entries[:last]
+	delete(s.index, key)
+	s.expiry.forget(key)
+	s.weight -= e.weight
+	return e.value, true
+}
+
+func (s *sampledCache[K, V]) expire(key K) {
+	if value, removed := s.remove(key); removed {
+		s.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    key,
+			Value:  value,
+			Reason: cache.EvictionReasonExpired,
+		})
+	}
+}
+
+// purgeExpired is called by the background cleaner
+func (s *sampledCache[K, V]) purgeExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.expiry.popExpired() {
+		s.expire(key)
+	}
+}
+
+func (s *sampledCache[K, V]) Delete(key K) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delete(key)
+}
+
+func (s *sampledCache[K, V]) delete(key K) error {
+	if value, removed := s.remove(key); removed {
+		s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: value})
+		return nil
+	}
+	return common.ErrKeyNotFound
+}
+
+func (s *sampledCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return getMany(keys, s.get)
+}
+
+// SetMany updates present keys, then makes room for all new keys before storing any of them.
+// If the new keys outweigh the capacity, only as many of them as fit are stored.
+func (s *sampledCache[K, V]) SetMany(entries map[K]V) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	weights, err := weighMany(entries, s.weigher, s.capacity)
+	fresh, weight := fitting(freshKeys(entries, weights, s.update), weights, s.capacity)
+	s.evict(weight)
+	for _, key := range fresh {
+		s.insert(key, entries[key], weights[key])
+	}
+	trackStored(s.expiry, weights, s.index)
+	return err
+}
+
+func (s *sampledCache[K, V]) DeleteMany(keys []K) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return deleteMany(keys, s.delete)
+}
+
+func (s *sampledCache[K, V]) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = make([]sampledEntry[K, V], 0, s.weigher.sizeHint(s.capacity))
+	s.index = make(map[K]int, s.weigher.sizeHint(s.capacity))
+	s.weight = 0
+	s.expiry.reset()
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits entries in no particular order
+func (s *sampledCache[K, V]) Range(fn func(K, V) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.entries {
+		e := &s.entries[i]
+		if s.expiry.expired(e.key) {
+			continue
+		}
+		if !fn(e.key, e.value) {
+			return
+		}
+	}
+}
+
+func (s *sampledCache[K, V]) Weight() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.weight
+}
+
+// Stop terminates the background cleaner started by WithExpiration
+func (s *sampledCache[K, V]) Stop() {
+	s.expiry.stop()
+}
+
+func (s *sampledCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return s.events.Subscribe(callback)
+}
@@ -0,0 +1,70 @@
+package strategies_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSampledCacheScorers tests that a sample covering the whole cache evicts exactly the entry each scorer ranks lowest
+func TestSampledCacheScorers(t *testing.T) {
+	tests := map[string]struct {
+		score   strategies.Scorer[string, int]
+		evicted string
+	}{
+		"oldest access":    {strategies.OldestAccess[string, int], "b"},
+		"lowest frequency": {strategies.LowestFrequency[string, int], "a"},
+		"nearest expiry":   {strategies.NearestExpiry[string, int], "c"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := strategies.NewSampledCache[string, int](3, 3, tt.score)()
+			events := recordEvents(t, c)
+			ttl := c.(strategies.TTLCache[string, int])
+
+			// b is used most but longest ago, a is used least, c expires first
+			require.NoError(t, ttl.SetWithTTL("b", 2, time.Hour))
+			_, _ = c.Get("b")
+			_, _ = c.Get("b")
+			require.NoError(t, c.Set("a", 1))
+			require.NoError(t, ttl.SetWithTTL("c", 3, time.Minute))
+			_, _ = c.Get("c")
+
+			require.NoError(t, c.Set("d", 4))
+			assert.Equal(t, []string{tt.evicted}, evictedKeys(*events))
+		})
+	}
+}
+
+// TestSampledCacheRandom tests that random eviction keeps the cache within its capacity and picks varying victims
+func TestSampledCacheRandom(t *testing.T) {
+	c := strategies.NewSampledCache[int, int](100, 5, nil)()
+	for i := 0; i < 10_000; i++ {
+		require.NoError(t, c.Set(i, i))
+	}
+
+	var keys []int
+	c.Range(func(key, _ int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	assert.Len(t, keys, 100)
+	assert.Contains(t, keys, 9999, "the entry just stored is never the victim")
+	assert.Less(t, slices.Min(keys), 9900, "random eviction should not behave like FIFO")
+}
+
+// TestSampledCacheHitRate tests that sampling five entries comes close to exact LRU, and beats random eviction
+func TestSampledCacheHitRate(t *testing.T) {
+	const requests = 200_000
+	lru := hits(strategies.NewLruCache[int, int](500), requests)
+	sampled := hits(strategies.NewSampledCache[int, int](500, 5, strategies.OldestAccess[int, int]), requests)
+	random := hits(strategies.NewSampledCache[int, int](500, 5, nil), requests)
+
+	assert.InDelta(t, lru, sampled, 0.05*float64(lru))
+	assert.Greater(t, sampled, random)
+}
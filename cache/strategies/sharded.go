@@ -0,0 +1,242 @@
+package strategies
+
+import (
+	"errors"
+	"fmt"
+	"hash/maphash"
+	"math"
+
+	"github.com/kimvlry/caching/cache"
+)
+
+// Hasher maps a key to the 64-bit hash used to pick its shard
+type Hasher[K comparable] func(key K) uint64
+
+// NewDefaultHasher returns a randomly seeded Hasher.
+// Strings, integers and floats are hashed directly, with both zeros of a float hashed alike since they are equal.
+// Other key types are hashed through their fmt representation, which must agree with ==: keys that are equal
+// but print differently, like structs holding -0.0 and 0.0, would land in different shards and need their own Hasher.
+func NewDefaultHasher[K comparable]() Hasher[K] {
+	seed := maphash.MakeSeed()
+	return func(key K) uint64 {
+		switch k := any(key).(type) {
+		case string:
+			return maphash.String(seed, k)
+		case int:
+			return mix64(uint64(k))
+		case int32:
+			return mix64(uint64(k))
+		case int64:
+			return mix64(uint64(k))
+		case uint:
+			return mix64(uint64(k))
+		case uint32:
+			return mix64(uint64(k))
+		case uint64:
+			return mix64(k)
+		case float32:
+			return mix64(floatBits(float64(k)))
+		case float64:
+			return mix64(floatBits(k))
+		default:
+			var h maphash.Hash
+			h.SetSeed(seed)
+			_, _ = fmt.Fprintf(&h, "%v", key)
+			return h.Sum64()
+		}
+	}
+}
+
+// floatBits returns the bits of f, the same for -0.0 and 0.0
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+	return math.Float64bits(f)
+}
+
+// mix64 is the splitmix64 finalizer, it spreads sequential integers over the whole hash space
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// shardedCache partitions keys by hash across independent caches, so operations on different shards never contend
+type shardedCache[K comparable, V any] struct {
+	shards []cache.IterableCache[K, V]
+	hasher Hasher[K]
+}
+
+// NewShardedCache builds a cache that fans keys out across shards independent caches.
+// capacity is split evenly between shards and factory is called once per shard with that shard's share.
+// There are never more shards than capacity, so that every shard holds an entry without exceeding the capacity.
+// The sharded cache holds no lock of its own: build shards with WithConcurrency to share it between goroutines.
+// A nil hasher selects NewDefaultHasher.
+func NewShardedCache[K comparable, V any](
+	capacity, shards int,
+	hasher Hasher[K],
+	factory func(capacity int) CacheFactory[K, V],
+) CacheFactory[K, V] {
+	return func() cache.IterableCache[K, V] {
+		return newShardedCache[K, V](capacity, shards, hasher, factory)
+	}
+}
+
+func newShardedCache[K comparable, V any](
+	capacity, shards int,
+	hasher Hasher[K],
+	factory func(capacity int) CacheFactory[K, V],
+) cache.IterableCache[K, V] {
+	shards = max(min(shards, capacity), 1)
+	if hasher == nil {
+		hasher = NewDefaultHasher[K]()
+	}
+
+	s := &shardedCache[K, V]{
+		shards: make([]cache.IterableCache[K, V], shards),
+		hasher: hasher,
+	}
+	for i := range s.shards {
+		share := capacity / shards
+		if i < capacity%shards {
+			share++
+		}
+		s.shards[i] = factory(share)()
+	}
+	return s
+}
+
+func (s *shardedCache[K, V]) shardIndex(key K) int {
+	return int(s.hasher(key) % uint64(len(s.shards)))
+}
+
+func (s *shardedCache[K, V]) shardFor(key K) cache.IterableCache[K, V] {
+	return s.shards[s.shardIndex(key)]
+}
+
+func (s *shardedCache[K, V]) Get(key K) (V, error) {
+	return s.shardFor(key).Get(key)
+}
+
+func (s *shardedCache[K, V]) Set(key K, value V) error {
+	return s.shardFor(key).Set(key, value)
+}
+
+func (s *shardedCache[K, V]) Delete(key K) error {
+	return s.shardFor(key).Delete(key)
+}
+
+// GetMany groups keys by shard and issues one batch per shard
+func (s *shardedCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	found := make(map[K]V, len(keys))
+	for shard, shardKeys := range s.groupKeys(keys) {
+		shardFound, _ := cache.GetMany(s.shards[shard], shardKeys)
+		for k, v := range shardFound {
+			found[k] = v
+		}
+	}
+
+	var missing []K
+	for _, key := range keys {
+		if _, ok := found[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	return found, missing
+}
+
+func (s *shardedCache[K, V]) SetMany(entries map[K]V) error {
+	grouped := make(map[int]map[K]V)
+	for key, value := range entries {
+		shard := s.shardIndex(key)
+		if grouped[shard] == nil {
+			grouped[shard] = make(map[K]V)
+		}
+		grouped[shard][key] = value
+	}
+
+	var errs []error
+	for shard, shardEntries := range grouped {
+		if err := cache.SetMany(s.shards[shard], shardEntries); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *shardedCache[K, V]) DeleteMany(keys []K) int {
+	deleted := 0
+	for shard, shardKeys := range s.groupKeys(keys) {
+		deleted += cache.DeleteMany(s.shards[shard], shardKeys)
+	}
+	return deleted
+}
+
+func (s *shardedCache[K, V]) groupKeys(keys []K) map[int][]K {
+	grouped := make(map[int][]K)
+	for _, key := range keys {
+		shard := s.shardIndex(key)
+		grouped[shard] = append(grouped[shard], key)
+	}
+	return grouped
+}
+
+func (s *shardedCache[K, V]) Clear() {
+	for _, shard := range s.shards {
+		shard.Clear()
+	}
+}
+
+// Range visits the shards one after another; it is not a consistent snapshot across shards
+func (s *shardedCache[K, V]) Range(fn func(K, V) bool) {
+	stopped := false
+	for _, shard := range s.shards {
+		shard.Range(func(k K, v V) bool {
+			if !fn(k, v) {
+				stopped = true
+			}
+			return !stopped
+		})
+		if stopped {
+			return
+		}
+	}
+}
+
+// Weight sums the weight of the shards that report one; like Range, it is not a consistent snapshot across shards
+func (s *shardedCache[K, V]) Weight() int64 {
+	var total int64
+	for _, shard := range s.shards {
+		if weighted, ok := shard.(WeightedCache[K, V]); ok {
+			total += weighted.Weight()
+		}
+	}
+	return total
+}
+
+// OnEvent subscribes callback to every observable shard.
+// Shards emit independently, so callback may be invoked concurrently.
+// When the shards share one dispatcher (see WithEventDispatcher), subscribe on that dispatcher instead:
+// subscribing here would register callback once per shard.
+func (s *shardedCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	var subs shardSubscriptions
+	for _, shard := range s.shards {
+		if observable, ok := shard.(cache.ObservableCache[K, V]); ok {
+			subs = append(subs, observable.OnEvent(callback))
+		}
+	}
+	return subs
+}
+
+// shardSubscriptions unsubscribes from every shard at once
+type shardSubscriptions []cache.Subscription
+
+func (subs shardSubscriptions) Unsubscribe() {
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+}
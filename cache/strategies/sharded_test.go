@@ -0,0 +1,160 @@
+package strategies_test
+
+import (
+	"math"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func concurrentLru(capacity int) strategies.CacheFactory[string, int] {
+	return strategies.NewLruCache[string, int](capacity, strategies.WithConcurrency[string, int]())
+}
+
+// TestShardedCache tests the NewShardedCache cache implementation
+func TestShardedCache(t *testing.T) {
+	var shardCapacities []int
+	c := strategies.NewShardedCache[string, int](22, 4, nil, func(capacity int) strategies.CacheFactory[string, int] {
+		shardCapacities = append(shardCapacities, capacity)
+		return concurrentLru(capacity)
+	})()
+
+	assert.Equal(t, []int{6, 6, 5, 5}, shardCapacities, "capacity should be split across shards")
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, c.Set(strconv.Itoa(i), i))
+	}
+	for i := 0; i < 5; i++ {
+		val, err := c.Get(strconv.Itoa(i))
+		require.NoError(t, err)
+		assert.Equal(t, i, val)
+	}
+
+	require.NoError(t, c.Delete("0"))
+	_, err := c.Get("0")
+	assert.Error(t, err)
+
+	seen := map[string]int{}
+	c.Range(func(k string, v int) bool {
+		seen[k] = v
+		return true
+	})
+	assert.Equal(t, map[string]int{"1": 1, "2": 2, "3": 3, "4": 4}, seen)
+
+	visited := 0
+	c.Range(func(string, int) bool {
+		visited++
+		return false
+	})
+	assert.Equal(t, 1, visited, "Range should stop across shards")
+
+	c.Clear()
+	_, err = c.Get("1")
+	assert.Error(t, err)
+}
+
+// TestShardedCacheFewerEntriesThanShards tests that a capacity below the number of shards is not exceeded
+func TestShardedCacheFewerEntriesThanShards(t *testing.T) {
+	var shardCapacities []int
+	c := strategies.NewShardedCache[string, int](3, 8, nil, func(capacity int) strategies.CacheFactory[string, int] {
+		shardCapacities = append(shardCapacities, capacity)
+		return concurrentLru(capacity)
+	})()
+	assert.Equal(t, []int{1, 1, 1}, shardCapacities)
+
+	for i := 0; i < 20; i++ {
+		require.NoError(t, c.Set(strconv.Itoa(i), i))
+	}
+	assert.LessOrEqual(t, c.(strategies.WeightedCache[string, int]).Weight(), int64(3))
+}
+
+// TestShardedCacheHasher tests that keys are routed by the supplied hasher
+func TestShardedCacheHasher(t *testing.T) {
+	// Every key lands in shard 0, whose capacity is 2
+	c := strategies.NewShardedCache[string, int](8, 4, func(string) uint64 { return 0 }, concurrentLru)()
+
+	_ = c.Set("a", 1)
+	_ = c.Set("b", 2)
+	_ = c.Set("c", 3)
+
+	_, err := c.Get("a")
+	assert.Error(t, err, "a should be evicted from the single used shard")
+}
+
+// TestDefaultHasherFloatZero tests that both zeros of a float key, which are equal, land in the same shard
+func TestDefaultHasherFloatZero(t *testing.T) {
+	negativeZero := math.Copysign(0, -1)
+	hasher := strategies.NewDefaultHasher[float64]()
+	assert.Equal(t, hasher(0), hasher(negativeZero))
+	assert.Equal(t, strategies.NewDefaultHasher[float32]()(0), strategies.NewDefaultHasher[float32]()(float32(negativeZero)))
+
+	c := strategies.NewShardedCache[float64, int](64, 64, nil, func(capacity int) strategies.CacheFactory[float64, int] {
+		return strategies.NewLruCache[float64, int](capacity)
+	})()
+	require.NoError(t, c.Set(negativeZero, 1))
+	val, err := c.Get(0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, val)
+}
+
+// TestShardedCacheEvents tests that events of every shard are forwarded
+func TestShardedCacheEvents(t *testing.T) {
+	c := strategies.NewShardedCache[string, int](4, 4, nil, concurrentLru)()
+
+	var evictions atomic.Int64
+	observable, ok := c.(cache.ObservableCache[string, int])
+	require.True(t, ok, "sharded cache should implement ObservableCache")
+	observable.OnEvent(func(event cache.Event[string, int]) {
+		if event.Type == cache.EventTypeEviction {
+			evictions.Add(1)
+		}
+	})
+
+	for i := 0; i < 100; i++ {
+		_ = c.Set(strconv.Itoa(i), i)
+	}
+
+	size := 0
+	c.Range(func(string, int) bool {
+		size++
+		return true
+	})
+	assert.Equal(t, 4, size)
+	assert.Equal(t, int64(96), evictions.Load())
+}
+
+func benchmarkParallel(b *testing.B, c cache.Cache[string, int]) {
+	keys := make([]string, 4096)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+		_ = c.Set(keys[i], i)
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[i%len(keys)]
+			if i%4 == 0 {
+				_ = c.Set(key, i)
+			} else {
+				_, _ = c.Get(key)
+			}
+			i++
+		}
+	})
+}
+
+// BenchmarkSingleLockLru is the baseline for BenchmarkShardedLru: run both with -cpu 1,2,4,8 to compare scaling
+func BenchmarkSingleLockLru(b *testing.B) {
+	benchmarkParallel(b, concurrentLru(4096)())
+}
+
+func BenchmarkShardedLru(b *testing.B) {
+	benchmarkParallel(b, strategies.NewShardedCache[string, int](4096, 64, nil, concurrentLru)())
+}
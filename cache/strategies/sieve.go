@@ -0,0 +1,218 @@
+package strategies
+
+import (
+	"container/list"
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+)
+
+// sieveCache implements SIEVE.
+// Entries stay in insertion order and a hit only sets their visited bit, so Get never moves list nodes.
+// A hand sweeps from the oldest entry towards the newest, clearing visited bits and evicting the first unvisited entry;
+// it keeps its position between evictions, so survivors are not rescanned until the hand wraps around.
+type sieveCache[K comparable, V any] struct {
+	capacity int
+	weight   int64
+	weigher  weigher[K, V]
+	data     map[K]*list.Element
+	queue    *list.List    // *sieveEntry, newest at the front
+	hand     *list.Element // next eviction candidate, nil to restart from the oldest entry
+	mu       sync.Locker
+
+	events *cache.EventDispatcher[K, V]
+}
+
+type sieveEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	weight  int64
+	visited bool
+}
+
+func newSieveCache[K comparable, V any](capacity int, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	cfg := newConfig(opts)
+	return &sieveCache[K, V]{
+		capacity: capacity,
+		weigher:  cfg.weigher,
+		data:     make(map[K]*list.Element, cfg.weigher.sizeHint(capacity)),
+		queue:    list.New(),
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
+	}
+}
+
+func (s *sieveCache[K, V]) Get(key K) (V, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(key)
+}
+
+func (s *sieveCache[K, V]) get(key K) (V, error) {
+	if elem, exists := s.data[key]; exists {
+		e := elem.Value.(*sieveEntry[K, V])
+		e.visited = true
+		s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: e.value})
+		return e.value, nil
+	}
+
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+	var zero V
+	return zero, common.ErrKeyNotFound
+}
+
+func (s *sieveCache[K, V]) Set(key K, value V) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	weight, err := s.weigher.admit(key, value, s.capacity)
+	if err != nil {
+		return err
+	}
+	if s.update(key, value, weight) {
+		// The new value may weigh more than the old one
+		s.evict(0)
+		return nil
+	}
+	s.evict(weight)
+	s.insert(key, value, weight)
+	return nil
+}
+
+// update overwrites the value of a present key and marks it as visited
+func (s *sieveCache[K, V]) update(key K, value V, weight int64) bool {
+	elem, exists := s.data[key]
+	if !exists {
+		return false
+	}
+	e := elem.Value.(*sieveEntry[K, V])
+	s.weight += weight - e.weight
+	e.value, e.weight = value, weight
+	e.visited = true
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+	return true
+}
+
+func (s *sieveCache[K, V]) insert(key K, value V, weight int64) {
+	s.data[key] = s.queue.PushFront(&sieveEntry[K, V]{key: key, value: value, weight: weight})
+	s.weight += weight
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+}
+
+// evict removes unvisited entries, moving the hand past the visited ones, until weight more fits into the capacity
+func (s *sieveCache[K, V]) evict(weight int64) {
+	for s.weight+weight > int64(s.capacity) && s.queue.Len() > 0 {
+		elem := s.hand
+		if elem == nil {
+			elem = s.queue.Back()
+		}
+		for e := elem.Value.(*sieveEntry[K, V]); e.visited; e = elem.Value.(*sieveEntry[K, V]) {
+			e.visited = false
+			if elem = elem.Prev(); elem == nil {
+				elem = s.queue.Back()
+			}
+		}
+
+		evicted := elem.Value.(*sieveEntry[K, V])
+		s.hand = elem
+		s.remove(evicted.key)
+		s.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    evicted.key,
+			Value:  evicted.value,
+			Reason: cache.EvictionReasonCapacity,
+		})
+	}
+}
+
+// remove unlinks an entry without emitting an event, stepping the hand over it
+func (s *sieveCache[K, V]) remove(key K) (V, bool) {
+	elem, exists := s.data[key]
+	if !exists {
+		var zero V
+		return zero, false
+	}
+	if s.hand == elem {
+		s.hand = elem.Prev()
+	}
+	s.queue.Remove(elem)
+	delete(s.data, key)
+	e := elem.Value.(*sieveEntry[K, V])
+	s.weight -= e.weight
+	return e.value, true
+}
+
+func (s *sieveCache[K, V]) Delete(key K) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delete(key)
+}
+
+func (s *sieveCache[K, V]) delete(key K) error {
+	if value, removed := s.remove(key); removed {
+		s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: value})
+		return nil
+	}
+	return common.ErrKeyNotFound
+}
+
+func (s *sieveCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return getMany(keys, s.get)
+}
+
+// SetMany updates present keys, then makes room for all new keys in a single eviction pass.
+// If the new keys outweigh the capacity, only as many of them as fit are stored.
+func (s *sieveCache[K, V]) SetMany(entries map[K]V) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	weights, err := weighMany(entries, s.weigher, s.capacity)
+	fresh, weight := fitting(freshKeys(entries, weights, s.update), weights, s.capacity)
+	s.evict(weight)
+	for _, key := range fresh {
+		s.insert(key, entries[key], weights[key])
+	}
+	return err
+}
+
+func (s *sieveCache[K, V]) DeleteMany(keys []K) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return deleteMany(keys, s.delete)
+}
+
+func (s *sieveCache[K, V]) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data = make(map[K]*list.Element, s.weigher.sizeHint(s.capacity))
+	s.queue = list.New()
+	s.hand = nil
+	s.weight = 0
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits entries from the oldest to the newest
+func (s *sieveCache[K, V]) Range(fn func(K, V) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for elem := s.queue.Back(); elem != nil; elem = elem.Prev() {
+		e := elem.Value.(*sieveEntry[K, V])
+		if !fn(e.key, e.value) {
+			break
+		}
+	}
+}
+
+func (s *sieveCache[K, V]) Weight() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.weight
+}
+
+func (s *sieveCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return s.events.Subscribe(callback)
+}
@@ -0,0 +1,97 @@
+package strategies_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func keysOf(c cache.IterableCache[string, int]) []string {
+	var keys []string
+	c.Range(func(key string, _ int) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// hits replays a deterministic Zipf-distributed trace and counts the hits of a cache built by factory
+func hits(factory strategies.CacheFactory[int, int], requests int) int {
+	c := factory()
+	zipf := rand.NewZipf(rand.New(rand.NewSource(1)), 1.1, 1, 10_000)
+	count := 0
+	for i := 0; i < requests; i++ {
+		key := int(zipf.Uint64())
+		if _, err := c.Get(key); err == nil {
+			count++
+		} else {
+			_ = c.Set(key, key)
+		}
+	}
+	return count
+}
+
+// TestSieveCache tests that the hand skips visited entries and resumes where it stopped
+func TestSieveCache(t *testing.T) {
+	c := strategies.NewSieveCache[string, int](3)()
+
+	require.NoError(t, c.Set("a", 1))
+	require.NoError(t, c.Set("b", 2))
+	require.NoError(t, c.Set("c", 3))
+	_, err := c.Get("a")
+	require.NoError(t, err)
+
+	// "a" was visited, so the hand spares it and evicts "b", then resumes at "c"
+	require.NoError(t, c.Set("d", 4))
+	assert.Equal(t, []string{"a", "c", "d"}, keysOf(c))
+	require.NoError(t, c.Set("e", 5))
+	assert.Equal(t, []string{"a", "d", "e"}, keysOf(c))
+
+	// The hand pointed at "d"; deleting it moves the hand on to "e", even though "a" is older
+	require.NoError(t, c.Delete("d"))
+	require.NoError(t, c.Set("f", 6))
+	require.NoError(t, c.Set("g", 7))
+	assert.Equal(t, []string{"a", "f", "g"}, keysOf(c))
+}
+
+// TestS3FifoCache tests quick demotion of one-hit wonders and the ghost queue
+func TestS3FifoCache(t *testing.T) {
+	c := strategies.NewS3FifoCache[string, int](10)()
+
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		require.NoError(t, c.Set(key, 0))
+	}
+	// "a" is hit twice, so it moves to the main queue instead of being evicted
+	_, _ = c.Get("a")
+	_, _ = c.Get("a")
+
+	require.NoError(t, c.Set("k", 0))
+	_, err := c.Get("a")
+	assert.NoError(t, err)
+	_, err = c.Get("b")
+	assert.Error(t, err, "one-hit wonder should be demoted quickly")
+
+	// "b" is remembered by the ghost queue and comes back to the main queue
+	require.NoError(t, c.Set("b", 1))
+	keys := keysOf(c)
+	assert.Equal(t, []string{"a", "b"}, keys[len(keys)-2:])
+}
+
+// TestQueuePoliciesHitRate tests that the visited bits of SIEVE and S3-FIFO beat plain FIFO on a skewed workload
+func TestQueuePoliciesHitRate(t *testing.T) {
+	const requests = 50_000
+
+	fifo := hits(strategies.NewFifoCache[int, int](500), requests)
+	lru := hits(strategies.NewLruCache[int, int](500), requests)
+	sieve := hits(strategies.NewSieveCache[int, int](500), requests)
+	s3fifo := hits(strategies.NewS3FifoCache[int, int](500), requests)
+
+	assert.Greater(t, sieve, fifo)
+	assert.Greater(t, s3fifo, fifo)
+	assert.Greater(t, sieve, lru*95/100)
+	assert.Greater(t, s3fifo, lru*95/100)
+}
@@ -0,0 +1,108 @@
+package strategies
+
+import (
+	"encoding/binary"
+	"github.com/bits-and-blooms/bloom/v3"
+	"math/bits"
+)
+
+const (
+	sketchDepth      = 4
+	sketchMaxCounter = 15 // counters saturate like the 4-bit counters of the TinyLFU paper
+	sketchSampleRate = 10 // additions per cached entry between two resets
+)
+
+// frequencySketch estimates how often keys were seen recently.
+// A doorkeeper bloom filter absorbs the first occurrence of every key, so one-hit wonders never reach
+// the count-min sketch. After a sample of additions all counters are halved and the doorkeeper is cleared,
+// so old popularity fades away.
+type frequencySketch[K comparable] struct {
+	hasher     Hasher[K]
+	counters   [sketchDepth][]uint8
+	mask       uint64
+	doorkeeper *bloom.BloomFilter
+
+	additions  int
+	sampleSize int
+}
+
+func newFrequencySketch[K comparable](capacity int, hasher Hasher[K]) *frequencySketch[K] {
+	capacity = max(capacity, 16)
+	width := 1 << bits.Len(uint(capacity-1))
+	s := &frequencySketch[K]{
+		hasher:     hasher,
+		mask:       uint64(width - 1),
+		doorkeeper: bloom.NewWithEstimates(uint(capacity), 0.01),
+		sampleSize: sketchSampleRate * capacity,
+	}
+	for i := range s.counters {
+		s.counters[i] = make([]uint8, width)
+	}
+	return s
+}
+
+// ensureCapacity makes room for twice as many entries once the sketch was sized for fewer than entries,
+// forgetting the frequencies seen so far. Weighted caches call it as they grow, since their capacity does not
+// count entries.
+func (s *frequencySketch[K]) ensureCapacity(entries int) {
+	if entries > s.sampleSize/sketchSampleRate {
+		*s = *newFrequencySketch[K](2*entries, s.hasher)
+	}
+}
+
+// increment records an occurrence of key
+func (s *frequencySketch[K]) increment(key K) {
+	h := s.hasher(key)
+	if !s.doorkeeper.TestAndAdd(hashBytes(h)) {
+		s.added()
+		return
+	}
+	for i := range s.counters {
+		if idx := s.index(h, i); s.counters[i][idx] < sketchMaxCounter {
+			s.counters[i][idx]++
+		}
+	}
+	s.added()
+}
+
+// estimate returns the approximate number of recent occurrences of key
+func (s *frequencySketch[K]) estimate(key K) int {
+	h := s.hasher(key)
+	count := uint8(sketchMaxCounter)
+	for i := range s.counters {
+		count = min(count, s.counters[i][s.index(h, i)])
+	}
+	if s.doorkeeper.Test(hashBytes(h)) {
+		return int(count) + 1
+	}
+	return int(count)
+}
+
+func (s *frequencySketch[K]) added() {
+	s.additions++
+	if s.additions >= s.sampleSize {
+		s.reset()
+	}
+}
+
+// reset halves every counter and clears the doorkeeper
+func (s *frequencySketch[K]) reset() {
+	for i := range s.counters {
+		for j := range s.counters[i] {
+			s.counters[i][j] >>= 1
+		}
+	}
+	s.doorkeeper.ClearAll()
+	s.additions /= 2
+}
+
+// index picks the counter of key in row i, rehashing the key hash with a per-row seed
+func (s *frequencySketch[K]) index(h uint64, i int) uint64 {
+	return mix64(h+uint64(i)*0x9e3779b97f4a7c15) & s.mask
+}
+
+func hashBytes(h uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], h)
+	return b[:]
+}
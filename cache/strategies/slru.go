@@ -0,0 +1,212 @@
+package strategies
+
+import (
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+)
+
+// slruCache implements a Segmented LRU cache.
+// New entries enter the probationary segment; a hit promotes them to the protected segment, whose least recently
+// used entries are demoted back to probation when it outgrows its share. Evictions only hit probation while it holds
+// entries, so entries used once cannot push out entries used at least twice.
+type slruCache[K comparable, V any] struct {
+	capacity     int
+	protectedCap int64
+	weigher      weigher[K, V]
+
+	probation *cacheList[K, V] // most recently used at the front
+	protected *cacheList[K, V]
+	mu        sync.Locker
+
+	events *cache.EventDispatcher[K, V]
+}
+
+func newSlruCache[K comparable, V any](capacity int, protectedRatio float64, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	capacity = max(capacity, 1)
+	protectedRatio = min(max(protectedRatio, 0), 1)
+	cfg := newConfig(opts)
+	return &slruCache[K, V]{
+		capacity:     capacity,
+		protectedCap: int64(float64(capacity) * protectedRatio),
+		weigher:      cfg.weigher,
+		probation:    newCacheList[K, V](false),
+		protected:    newCacheList[K, V](false),
+		mu:           cfg.locker(),
+		events:       cfg.dispatcher(),
+	}
+}
+
+func (s *slruCache[K, V]) Get(key K) (V, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.get(key)
+}
+
+func (s *slruCache[K, V]) get(key K) (V, error) {
+	if elem, ok := s.probation.m[key]; ok {
+		e := elem.Value.(*entry[K, V])
+		s.promote(key, e.value, e.weight)
+		s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: e.value})
+		return e.value, nil
+	}
+	if elem, ok := s.protected.m[key]; ok {
+		s.protected.l.MoveToFront(elem)
+		value := elem.Value.(*entry[K, V]).value
+		s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: value})
+		return value, nil
+	}
+
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+	var zero V
+	return zero, common.ErrKeyNotFound
+}
+
+// promote moves a probationary entry to the protected segment, demoting the protected entries that no longer fit
+func (s *slruCache[K, V]) promote(key K, value V, weight int64) {
+	s.probation.remove(key)
+	s.protected.addFront(key, value, weight)
+	for s.protected.size > s.protectedCap {
+		demoted := s.protected.l.Back().Value.(*entry[K, V])
+		s.protected.remove(demoted.key)
+		s.probation.addFront(demoted.key, demoted.value, demoted.weight)
+		s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDemotion, Key: demoted.key, Value: demoted.value})
+	}
+}
+
+func (s *slruCache[K, V]) Set(key K, value V) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	weight, err := s.weigher.admit(key, value, s.capacity)
+	if err != nil {
+		return err
+	}
+	if s.update(key, value, weight) {
+		// The new value may weigh more than the old one
+		s.evict(0)
+		return nil
+	}
+	s.evict(weight)
+	s.insert(key, value, weight)
+	return nil
+}
+
+// update overwrites the value of a present key, counting the write as a hit
+func (s *slruCache[K, V]) update(key K, value V, weight int64) bool {
+	switch {
+	case s.probation.m[key] != nil:
+		s.promote(key, value, weight)
+	case s.protected.m[key] != nil:
+		s.protected.moveToFront(key, value, weight)
+	default:
+		return false
+	}
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+	return true
+}
+
+func (s *slruCache[K, V]) insert(key K, value V, weight int64) {
+	s.probation.addFront(key, value, weight)
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+}
+
+// evict removes least recently used entries, from probation first, until weight more fits into the capacity
+func (s *slruCache[K, V]) evict(weight int64) {
+	for s.probation.size+s.protected.size+weight > int64(s.capacity) && s.len() > 0 {
+		segment := s.probation
+		if segment.len() == 0 {
+			segment = s.protected
+		}
+		evicted := segment.l.Back().Value.(*entry[K, V])
+		segment.remove(evicted.key)
+		s.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    evicted.key,
+			Value:  evicted.value,
+			Reason: cache.EvictionReasonCapacity,
+		})
+	}
+}
+
+func (s *slruCache[K, V]) len() int {
+	return s.probation.len() + s.protected.len()
+}
+
+func (s *slruCache[K, V]) Delete(key K) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delete(key)
+}
+
+func (s *slruCache[K, V]) delete(key K) error {
+	for _, segment := range []*cacheList[K, V]{s.probation, s.protected} {
+		if elem, ok := segment.m[key]; ok {
+			segment.remove(key)
+			s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: elem.Value.(*entry[K, V]).value})
+			return nil
+		}
+	}
+	return common.ErrKeyNotFound
+}
+
+func (s *slruCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return getMany(keys, s.get)
+}
+
+// SetMany updates present keys, then makes room for all new keys in a single eviction pass.
+// If the new keys outweigh the capacity, only as many of them as fit are stored.
+func (s *slruCache[K, V]) SetMany(entries map[K]V) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	weights, err := weighMany(entries, s.weigher, s.capacity)
+	fresh, weight := fitting(freshKeys(entries, weights, s.update), weights, s.capacity)
+	s.evict(weight)
+	for _, key := range fresh {
+		s.insert(key, entries[key], weights[key])
+	}
+	return err
+}
+
+func (s *slruCache[K, V]) DeleteMany(keys []K) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return deleteMany(keys, s.delete)
+}
+
+func (s *slruCache[K, V]) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.probation = newCacheList[K, V](false)
+	s.protected = newCacheList[K, V](false)
+	s.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits probation, then the protected segment, each from the most to the least recently used entry
+func (s *slruCache[K, V]) Range(fn func(K, V) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, segment := range []*cacheList[K, V]{s.probation, s.protected} {
+		for elem := segment.l.Front(); elem != nil; elem = elem.Next() {
+			e := elem.Value.(*entry[K, V])
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+func (s *slruCache[K, V]) Weight() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.probation.size + s.protected.size
+}
+
+func (s *slruCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return s.events.Subscribe(callback)
+}
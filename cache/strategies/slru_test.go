@@ -0,0 +1,99 @@
+package strategies_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSlruCache tests promotion, demotion events and that evictions hit probation first
+func TestSlruCache(t *testing.T) {
+	c := strategies.NewSlruCache[string, int](4, 0.5)()
+	events := recordEvents(t, c)
+
+	for i, key := range []string{"a", "b", "c", "d"} {
+		require.NoError(t, c.Set(key, i))
+	}
+	_, _ = c.Get("a")
+	_, _ = c.Get("b")
+	// The protected segment holds two entries: promoting "c" demotes "a", its least recently used entry
+	_, _ = c.Get("c")
+
+	var demoted []string
+	for _, event := range *events {
+		if event.Type == cache.EventTypeDemotion {
+			demoted = append(demoted, event.Key)
+		}
+	}
+	assert.Equal(t, []string{"a"}, demoted)
+
+	// Probation now holds "a" and "d"; "d" was used least recently and goes first
+	require.NoError(t, c.Set("e", 4))
+	_, err := c.Get("d")
+	assert.Error(t, err)
+	for _, key := range []string{"b", "c", "e"} {
+		_, err := c.Get(key)
+		assert.NoError(t, err, key)
+	}
+}
+
+// TestTwoQueueCache tests that only keys referenced again after leaving A1in enter the main queue
+func TestTwoQueueCache(t *testing.T) {
+	c := strategies.NewTwoQueueCache[string, int](4)()
+
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		require.NoError(t, c.Set(key, i))
+	}
+	_, err := c.Get("a")
+	assert.Error(t, err, "the oldest A1in entry should be evicted into A1out")
+
+	// "a" is remembered by A1out, so it comes back into Am and survives a scan
+	require.NoError(t, c.Set("a", 10))
+	for i := 0; i < 100; i++ {
+		require.NoError(t, c.Set(fmt.Sprintf("scan-%d", i), i))
+	}
+	val, err := c.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, 10, val)
+}
+
+// TestSegmentedPoliciesScanResistance tests that SLRU and 2Q keep entries used more than once through a scan
+func TestSegmentedPoliciesScanResistance(t *testing.T) {
+	survivors := func(factory strategies.CacheFactory[string, int]) int {
+		c := factory()
+		cold := 0
+		for round := 0; round < 5; round++ {
+			for i := 0; i < 50; i++ {
+				key := fmt.Sprintf("hot-%d", i)
+				if _, err := c.Get(key); err != nil {
+					_ = c.Set(key, i)
+				}
+			}
+			for i := 0; i < 30; i++ {
+				_ = c.Set(fmt.Sprintf("cold-%d", cold), cold)
+				cold++
+			}
+		}
+		for i := 0; i < 1000; i++ {
+			_ = c.Set(fmt.Sprintf("scan-%d", i), i)
+		}
+
+		count := 0
+		c.Range(func(key string, _ int) bool {
+			if strings.HasPrefix(key, "hot-") {
+				count++
+			}
+			return true
+		})
+		return count
+	}
+
+	assert.Zero(t, survivors(strategies.NewLruCache[string, int](100)))
+	assert.Equal(t, 50, survivors(strategies.NewSlruCache[string, int](100, 0.8)))
+	assert.Equal(t, 50, survivors(strategies.NewTwoQueueCache[string, int](100)))
+}
@@ -0,0 +1,178 @@
+package strategies
+
+import (
+	"container/list"
+	"github.com/kimvlry/caching/cache/strategies/priority_heap/heap_item"
+	"time"
+)
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelMask   = wheelSlots - 1
+	wheelLevels = 6 // 2^36 ticks, over two years at a millisecond resolution
+)
+
+// timingWheel is a hierarchical timing wheel (Varghese and Lauck) indexing the deadlines of the TTL cache.
+// Time is cut into ticks of the resolution. Level 0 has a slot per tick of the current block of 64 ticks, level 1 a slot
+// per block of 64 ticks of the current block of 64², and so on; an entry goes to the lowest level whose current block
+// contains its deadline, so pushing, moving and removing an entry are O(1). When the wheel enters a new block,
+// the slot of the higher level covering it is cascaded: its entries are placed again, one level lower or more.
+// Deadlines beyond the top level wait in its slots and are placed again whenever their slot is cascaded.
+type timingWheel[K comparable, V any] struct {
+	resolution int64
+	tick       int64 // next tick to expire
+
+	slots  [wheelLevels][wheelSlots]*list.List // *wheelEntry, nil while empty
+	counts [wheelLevels]int
+	elems  map[K]*list.Element
+}
+
+type wheelEntry[K comparable, V any] struct {
+	item        heap_item.Item[K, V]
+	level, slot int
+}
+
+func newTimingWheel[K comparable, V any](resolution time.Duration, now time.Time) *timingWheel[K, V] {
+	w := &timingWheel[K, V]{
+		resolution: int64(resolution),
+		elems:      make(map[K]*list.Element),
+	}
+	w.tick = w.floor(now.UnixNano())
+	return w
+}
+
+func (w *timingWheel[K, V]) floor(nanos int64) int64 {
+	tick := nanos / w.resolution
+	if nanos%w.resolution < 0 {
+		tick--
+	}
+	return tick
+}
+
+// due returns the first tick at which an entry is expired: a deadline inside a tick is rounded up to its end
+func (w *timingWheel[K, V]) due(item heap_item.Item[K, V]) int64 {
+	deadline := item.GetPriority()
+	tick := deadline / w.resolution
+	if deadline%w.resolution > 0 {
+		tick++
+	}
+	return tick
+}
+
+func (w *timingWheel[K, V]) push(item heap_item.Item[K, V]) {
+	w.place(&wheelEntry[K, V]{item: item})
+}
+
+// place puts an entry in its slot; a deadline the wheel has already turned past goes to the next tick
+func (w *timingWheel[K, V]) place(e *wheelEntry[K, V]) {
+	due := max(w.due(e.item), w.tick)
+	level := 0
+	for level < wheelLevels-1 && due>>(wheelBits*(level+1)) != w.tick>>(wheelBits*(level+1)) {
+		level++
+	}
+	e.level, e.slot = level, int(due>>(wheelBits*level))&wheelMask
+
+	slot := w.slots[e.level][e.slot]
+	if slot == nil {
+		slot = list.New()
+		w.slots[e.level][e.slot] = slot
+	}
+	w.elems[e.item.GetKey()] = slot.PushBack(e)
+	w.counts[level]++
+}
+
+func (w *timingWheel[K, V]) fix(item heap_item.Item[K, V]) {
+	if e := w.unlink(item.GetKey()); e != nil {
+		w.place(e)
+	}
+}
+
+func (w *timingWheel[K, V]) remove(item heap_item.Item[K, V]) {
+	w.unlink(item.GetKey())
+}
+
+func (w *timingWheel[K, V]) unlink(key K) *wheelEntry[K, V] {
+	elem, exists := w.elems[key]
+	if !exists {
+		return nil
+	}
+	e := elem.Value.(*wheelEntry[K, V])
+	w.slots[e.level][e.slot].Remove(elem)
+	w.counts[e.level]--
+	delete(w.elems, key)
+	return e
+}
+
+// popSoonest removes the oldest entry of the earliest slot. Slots at level 0 span a tick, so victims are in deadline
+// order to within the resolution there, and to within the span of their slot at higher levels.
+func (w *timingWheel[K, V]) popSoonest() heap_item.Item[K, V] {
+	for level := 0; level < wheelLevels; level++ {
+		if w.counts[level] == 0 {
+			continue
+		}
+		current := int(w.tick>>(wheelBits*level)) & wheelMask
+		for i := 0; i < wheelSlots; i++ {
+			slot := w.slots[level][(current+i)&wheelMask]
+			if slot != nil && slot.Len() > 0 {
+				e := slot.Front().Value.(*wheelEntry[K, V])
+				w.unlink(e.item.GetKey())
+				return e.item
+			}
+		}
+	}
+	return nil
+}
+
+// popExpired turns the wheel up to now, returning the entries of every tick it passes.
+// Stretches of ticks that cannot hold an entry are skipped a block at a time.
+func (w *timingWheel[K, V]) popExpired(now time.Time) []heap_item.Item[K, V] {
+	end := w.floor(now.UnixNano())
+	var expired []heap_item.Item[K, V]
+	for w.tick <= end {
+		if len(w.elems) == 0 {
+			w.tick = end + 1
+			break
+		}
+		w.cascade()
+		if slot := w.slots[0][w.tick&wheelMask]; slot != nil {
+			for elem := slot.Front(); elem != nil; elem = slot.Front() {
+				expired = append(expired, w.unlink(elem.Value.(*wheelEntry[K, V]).item.GetKey()).item)
+			}
+		}
+
+		next := w.tick + 1
+		// With levels below L empty, nothing is due before the wheel enters the next block of level L
+		for level := 0; level < wheelLevels-1 && w.counts[level] == 0; level++ {
+			next = (w.tick | (1<<(wheelBits*(level+1)) - 1)) + 1
+		}
+		w.tick = min(next, end+1)
+	}
+	return expired
+}
+
+// cascade places again the entries of the slots whose block starts at the current tick, from the highest level down,
+// so entries coming down from a level land in slots still to be cascaded or expired
+func (w *timingWheel[K, V]) cascade() {
+	top := 0
+	for top < wheelLevels-1 && w.tick&(1<<(wheelBits*(top+1))-1) == 0 {
+		top++
+	}
+	for level := top; level > 0; level-- {
+		index := int(w.tick>>(wheelBits*level)) & wheelMask
+		slot := w.slots[level][index]
+		if slot == nil {
+			continue
+		}
+		w.slots[level][index] = nil
+		for elem := slot.Front(); elem != nil; elem = elem.Next() {
+			e := elem.Value.(*wheelEntry[K, V])
+			w.counts[level]--
+			w.place(e)
+		}
+	}
+}
+
+func (w *timingWheel[K, V]) len() int {
+	return len(w.elems)
+}
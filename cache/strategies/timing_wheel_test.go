@@ -0,0 +1,114 @@
+package strategies
+
+import (
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/priority_heap/heap_item"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTimingWheelExpiresOnTime tests that the wheel returns exactly the entries whose deadline has been reached,
+// through cascades, skipped stretches and deadlines beyond the top level. A nanosecond resolution makes ticks exact.
+func TestTimingWheelExpiresOnTime(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	now := int64(1) << 40
+	w := newTimingWheel[int, int](time.Nanosecond, time.Unix(0, now))
+	deadlines := make(map[int]int64)
+	items := make(map[int]heap_item.Item[int, int])
+
+	randomDeadline := func() int64 {
+		// Spread over every level, and past the top one (2^36 ticks)
+		return now + 1 + rng.Int63n(int64(1)<<rng.Intn(40))
+	}
+	for step := 0; step < 20_000; step++ {
+		key := rng.Intn(500)
+		switch item, exists := items[key]; {
+		case !exists:
+			deadlines[key] = randomDeadline()
+			items[key] = heap_item.NewTTLHeapItemAt(key, 0, time.Unix(0, deadlines[key]))
+			w.push(items[key])
+		case rng.Intn(3) == 0:
+			w.remove(item)
+			delete(items, key)
+			delete(deadlines, key)
+		default:
+			deadlines[key] = randomDeadline()
+			item.SetPriority(deadlines[key])
+			w.fix(item)
+		}
+
+		now += rng.Int63n(int64(1) << rng.Intn(36))
+		var want, got []int
+		for key, deadline := range deadlines {
+			if deadline <= now {
+				want = append(want, key)
+				delete(deadlines, key)
+				delete(items, key)
+			}
+		}
+		for _, item := range w.popExpired(time.Unix(0, now)) {
+			got = append(got, item.GetKey())
+		}
+		sort.Ints(want)
+		sort.Ints(got)
+		require.Equal(t, want, got, "step %d", step)
+		require.Equal(t, len(deadlines), w.len())
+	}
+}
+
+// TestTimingWheelPopSoonest tests that capacity victims come in deadline order, to within the span of a slot
+func TestTimingWheelPopSoonest(t *testing.T) {
+	start := time.Unix(0, 0)
+	w := newTimingWheel[string, int](time.Second, start)
+	for key, deadline := range map[string]time.Duration{
+		"hour":   time.Hour,
+		"second": time.Second,
+		"minute": time.Minute,
+		"day":    24 * time.Hour,
+	} {
+		w.push(heap_item.NewTTLHeapItemAt(key, 0, start.Add(deadline)))
+	}
+
+	var keys []string
+	for item := w.popSoonest(); item != nil; item = w.popSoonest() {
+		keys = append(keys, item.GetKey())
+	}
+	assert.Equal(t, []string{"second", "minute", "hour", "day"}, keys)
+	assert.Zero(t, w.len())
+}
+
+// TestTTLCacheTimingWheel tests that with a timing wheel, expired entries are dropped within a resolution or two
+// however long the default ttl is
+func TestTTLCacheTimingWheel(t *testing.T) {
+	c, clk := newFakeTtlCache[int](10, 24*time.Hour, WithTimingWheel[string, int](time.Second))
+
+	var mu sync.Mutex
+	var expired []string
+	c.(cache.ObservableCache[string, int]).OnEvent(func(event cache.Event[string, int]) {
+		if event.Type == cache.EventTypeEviction && event.Reason == cache.EvictionReasonExpired {
+			mu.Lock()
+			defer mu.Unlock()
+			expired = append(expired, event.Key)
+		}
+	})
+
+	require.NoError(t, c.(TTLCache[string, int]).SetWithTTL("short", 1, 5*time.Second))
+	require.NoError(t, c.Set("long", 2))
+	for i := 0; i < 7; i++ {
+		clk.Advance(time.Second)
+	}
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return assert.ObjectsAreEqual([]string{"short"}, expired)
+	}, time.Second, time.Millisecond)
+
+	_, err := c.Get("long")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,337 @@
+package strategies
+
+import (
+	"container/list"
+	"errors"
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+)
+
+const (
+	tinyLfuWindowPercent    = 1  // share of the capacity given to the admission window
+	tinyLfuProtectedPercent = 80 // share of the main region given to its protected segment
+)
+
+type tinyLfuSegment uint8
+
+const (
+	segmentWindow tinyLfuSegment = iota
+	segmentProbation
+	segmentProtected
+)
+
+// tinyLfuCache implements Window-TinyLFU.
+// New entries enter a small LRU window. Entries leaving the window compete with the victim of the main region,
+// a segmented LRU, and only the one the frequency sketch has seen more often stays, so scans of one-hit wonders
+// pass through the window without flushing the main region.
+type tinyLfuCache[K comparable, V any] struct {
+	capacity     int
+	windowCap    int64
+	protectedCap int64
+	weigher      weigher[K, V]
+
+	windowWeight    int64
+	probationWeight int64
+	protectedWeight int64
+
+	data      map[K]*list.Element
+	window    *list.List // *tinyLfuEntry, least recently used first
+	probation *list.List
+	protected *list.List
+	sketch    *frequencySketch[K]
+	mu        sync.Locker
+
+	events *cache.EventDispatcher[K, V]
+}
+
+type tinyLfuEntry[K comparable, V any] struct {
+	key     K
+	value   V
+	weight  int64
+	segment tinyLfuSegment
+}
+
+func newTinyLfuCache[K comparable, V any](capacity int, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	capacity = max(capacity, 1)
+	windowCap := max(capacity*tinyLfuWindowPercent/100, 1)
+	cfg := newConfig(opts)
+	return &tinyLfuCache[K, V]{
+		capacity:     capacity,
+		windowCap:    int64(windowCap),
+		protectedCap: int64((capacity - windowCap) * tinyLfuProtectedPercent / 100),
+		weigher:      cfg.weigher,
+		data:         make(map[K]*list.Element, cfg.weigher.sizeHint(capacity)),
+		window:       list.New(),
+		probation:    list.New(),
+		protected:    list.New(),
+		sketch:       newFrequencySketch[K](cfg.weigher.sizeHint(capacity), NewDefaultHasher[K]()),
+		mu:           cfg.locker(),
+		events:       cfg.dispatcher(),
+	}
+}
+
+func (t *tinyLfuCache[K, V]) Get(key K) (V, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.get(key)
+}
+
+func (t *tinyLfuCache[K, V]) get(key K) (V, error) {
+	t.sketch.increment(key)
+	elem, exists := t.data[key]
+	if !exists {
+		t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+		var zero V
+		return zero, common.ErrKeyNotFound
+	}
+
+	t.touch(elem)
+	value := elem.Value.(*tinyLfuEntry[K, V]).value
+	t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: value})
+	return value, nil
+}
+
+func (t *tinyLfuCache[K, V]) Set(key K, value V) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.set(key, value)
+}
+
+func (t *tinyLfuCache[K, V]) set(key K, value V) error {
+	weight, err := t.weigher.admit(key, value, t.capacity)
+	if err != nil {
+		return err
+	}
+	t.sketch.increment(key)
+	if elem, exists := t.data[key]; exists {
+		e := elem.Value.(*tinyLfuEntry[K, V])
+		*t.segmentWeight(e.segment) += weight - e.weight
+		e.value, e.weight = value, weight
+		t.touch(elem)
+		t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+		// The new value may weigh more than the old one
+		t.shrink()
+		return nil
+	}
+
+	for t.windowWeight+weight > t.windowCap && t.window.Len() > 0 {
+		t.admit(t.window.Front(), weight)
+	}
+	e := &tinyLfuEntry[K, V]{key: key, value: value, weight: weight, segment: segmentWindow}
+	t.data[key] = t.window.PushBack(e)
+	t.windowWeight += weight
+	t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+	// An entry heavier than the window can only fit at the expense of the main region
+	t.shrink()
+	if t.weigher != nil {
+		t.sketch.ensureCapacity(len(t.data))
+	}
+	return nil
+}
+
+// touch records a hit: window and protected entries become most recently used,
+// probation entries are promoted to the protected segment
+func (t *tinyLfuCache[K, V]) touch(elem *list.Element) {
+	e := elem.Value.(*tinyLfuEntry[K, V])
+	switch e.segment {
+	case segmentWindow:
+		t.window.MoveToBack(elem)
+	case segmentProtected:
+		t.protected.MoveToBack(elem)
+	case segmentProbation:
+		t.probation.Remove(elem)
+		t.probationWeight -= e.weight
+		e.segment = segmentProtected
+		t.data[e.key] = t.protected.PushBack(e)
+		t.protectedWeight += e.weight
+		for t.protectedWeight > t.protectedCap {
+			t.demote(t.protected.Front())
+		}
+	}
+}
+
+// demote moves a protected entry back to the most recently used end of probation
+func (t *tinyLfuCache[K, V]) demote(elem *list.Element) {
+	e := t.protected.Remove(elem).(*tinyLfuEntry[K, V])
+	t.protectedWeight -= e.weight
+	e.segment = segmentProbation
+	t.data[e.key] = t.probation.PushBack(e)
+	t.probationWeight += e.weight
+}
+
+// admit moves the candidate leaving the window into the main region, keeping room for incoming more weight.
+// While the cache would overflow, the candidate and the main region's victim compete:
+// the candidate is only admitted if the sketch has seen it more often than the victim.
+func (t *tinyLfuCache[K, V]) admit(elem *list.Element, incoming int64) {
+	candidate := t.window.Remove(elem).(*tinyLfuEntry[K, V])
+	t.windowWeight -= candidate.weight
+
+	for t.weight()+candidate.weight+incoming > int64(t.capacity) {
+		victim := t.victim()
+		if victim == nil || t.sketch.estimate(candidate.key) <= t.sketch.estimate(victim.key) {
+			t.evicted(candidate)
+			return
+		}
+		t.remove(victim.key)
+		t.evicted(victim)
+	}
+
+	candidate.segment = segmentProbation
+	t.data[candidate.key] = t.probation.PushBack(candidate)
+	t.probationWeight += candidate.weight
+}
+
+// victim returns the next entry to leave the main region, nil if it is empty
+func (t *tinyLfuCache[K, V]) victim() *tinyLfuEntry[K, V] {
+	elem := t.probation.Front()
+	if elem == nil {
+		elem = t.protected.Front()
+	}
+	if elem == nil {
+		return nil
+	}
+	return elem.Value.(*tinyLfuEntry[K, V])
+}
+
+// shrink evicts main region victims, then the oldest window entries, until the cache fits into its capacity
+func (t *tinyLfuCache[K, V]) shrink() {
+	for t.weight() > int64(t.capacity) {
+		victim := t.victim()
+		if victim == nil {
+			victim = t.window.Front().Value.(*tinyLfuEntry[K, V])
+		}
+		t.remove(victim.key)
+		t.evicted(victim)
+	}
+}
+
+func (t *tinyLfuCache[K, V]) weight() int64 {
+	return t.windowWeight + t.probationWeight + t.protectedWeight
+}
+
+func (t *tinyLfuCache[K, V]) evicted(e *tinyLfuEntry[K, V]) {
+	delete(t.data, e.key)
+	t.events.Emit(cache.Event[K, V]{
+		Type:   cache.EventTypeEviction,
+		Key:    e.key,
+		Value:  e.value,
+		Reason: cache.EvictionReasonCapacity,
+	})
+}
+
+// remove unlinks an entry from its segment without emitting an event
+func (t *tinyLfuCache[K, V]) remove(key K) (*tinyLfuEntry[K, V], bool) {
+	elem, exists := t.data[key]
+	if !exists {
+		return nil, false
+	}
+	e := elem.Value.(*tinyLfuEntry[K, V])
+	t.segmentList(e.segment).Remove(elem)
+	*t.segmentWeight(e.segment) -= e.weight
+	delete(t.data, key)
+	return e, true
+}
+
+func (t *tinyLfuCache[K, V]) segmentList(segment tinyLfuSegment) *list.List {
+	switch segment {
+	case segmentWindow:
+		return t.window
+	case segmentProbation:
+		return t.probation
+	default:
+		return t.protected
+	}
+}
+
+func (t *tinyLfuCache[K, V]) segmentWeight(segment tinyLfuSegment) *int64 {
+	switch segment {
+	case segmentWindow:
+		return &t.windowWeight
+	case segmentProbation:
+		return &t.probationWeight
+	default:
+		return &t.protectedWeight
+	}
+}
+
+func (t *tinyLfuCache[K, V]) Delete(key K) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.delete(key)
+}
+
+func (t *tinyLfuCache[K, V]) delete(key K) error {
+	e, removed := t.remove(key)
+	if !removed {
+		return common.ErrKeyNotFound
+	}
+	t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: e.value})
+	return nil
+}
+
+func (t *tinyLfuCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return getMany(keys, t.get)
+}
+
+// SetMany stores the entries under a single lock acquisition.
+// Every new key passes through the window and the admission filter on its own.
+func (t *tinyLfuCache[K, V]) SetMany(entries map[K]V) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var errs []error
+	for key, value := range entries {
+		if err := t.set(key, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (t *tinyLfuCache[K, V]) DeleteMany(keys []K) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return deleteMany(keys, t.delete)
+}
+
+// Clear removes all entries but keeps the frequency history, which describes the workload rather than the content
+func (t *tinyLfuCache[K, V]) Clear() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.data = make(map[K]*list.Element, t.weigher.sizeHint(t.capacity))
+	t.window = list.New()
+	t.probation = list.New()
+	t.protected = list.New()
+	t.windowWeight, t.probationWeight, t.protectedWeight = 0, 0, 0
+	t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits the window, then probation, then the protected segment
+func (t *tinyLfuCache[K, V]) Range(fn func(K, V) bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, segment := range []*list.List{t.window, t.probation, t.protected} {
+		for elem := segment.Front(); elem != nil; elem = elem.Next() {
+			e := elem.Value.(*tinyLfuEntry[K, V])
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+func (t *tinyLfuCache[K, V]) Weight() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.weight()
+}
+
+func (t *tinyLfuCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return t.events.Subscribe(callback)
+}
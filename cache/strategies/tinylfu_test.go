@@ -0,0 +1,90 @@
+package strategies_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTinyLFUCache tests basic operations of the NewTinyLfuCache cache implementation
+func TestTinyLFUCache(t *testing.T) {
+	c := strategies.NewTinyLfuCache[string, int](100)()
+
+	for i := 0; i < 100; i++ {
+		require.NoError(t, c.Set(fmt.Sprint(i), i))
+	}
+	val, err := c.Get("42")
+	require.NoError(t, err)
+	assert.Equal(t, 42, val)
+
+	require.NoError(t, c.Set("42", 0))
+	val, err = c.Get("42")
+	require.NoError(t, err)
+	assert.Equal(t, 0, val)
+
+	require.NoError(t, c.Delete("42"))
+	_, err = c.Get("42")
+	assert.Error(t, err)
+	assert.Error(t, c.Delete("42"))
+}
+
+// TestTinyLFUCacheScanResistance tests that a scan of one-hit wonders does not flush frequently used entries
+func TestTinyLFUCacheScanResistance(t *testing.T) {
+	survivors := func(factory strategies.CacheFactory[string, int]) int {
+		c := factory()
+		for round := 0; round < 5; round++ {
+			for i := 0; i < 50; i++ {
+				key := fmt.Sprintf("hot-%d", i)
+				if _, err := c.Get(key); err != nil {
+					_ = c.Set(key, i)
+				}
+			}
+		}
+		for i := 0; i < 1000; i++ {
+			_ = c.Set(fmt.Sprintf("scan-%d", i), i)
+		}
+
+		count := 0
+		c.Range(func(key string, _ int) bool {
+			if key[:4] == "hot-" {
+				count++
+			}
+			return true
+		})
+		return count
+	}
+
+	assert.Zero(t, survivors(strategies.NewLruCache[string, int](100)))
+	assert.GreaterOrEqual(t, survivors(strategies.NewTinyLfuCache[string, int](100)), 45)
+}
+
+// TestTinyLFUCacheAdmitsNewHotKeys tests that a key used often enough replaces a main region entry
+func TestTinyLFUCacheAdmitsNewHotKeys(t *testing.T) {
+	c := strategies.NewTinyLfuCache[string, int](10)()
+	for i := 0; i < 10; i++ {
+		_ = c.Set(fmt.Sprint(i), i)
+	}
+
+	var evicted []string
+	c.(cache.ObservableCache[string, int]).OnEvent(func(event cache.Event[string, int]) {
+		if event.Type == cache.EventTypeEviction {
+			evicted = append(evicted, event.Key)
+		}
+	})
+
+	for i := 0; i < 5; i++ {
+		_, _ = c.Get("new")
+	}
+	_ = c.Set("new", 1)
+	_ = c.Set("next", 2)
+
+	val, err := c.Get("new")
+	require.NoError(t, err)
+	assert.Equal(t, 1, val)
+	// "9" left the window as a one-hit wonder and was rejected, "new" then replaced the main region's victim
+	assert.Equal(t, []string{"9", "0"}, evicted)
+}
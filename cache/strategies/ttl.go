@@ -2,10 +2,15 @@ package strategies
 
 import (
 	"container/heap"
+	"context"
+	"fmt"
 	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/clock"
 	"github.com/kimvlry/caching/cache/strategies/common"
 	"github.com/kimvlry/caching/cache/strategies/priority_heap"
 	"github.com/kimvlry/caching/cache/strategies/priority_heap/heap_item"
+	"math"
+	"math/rand"
 	"sync"
 	"time"
 )
@@ -17,29 +22,169 @@ type TTLCache[K comparable, V any] interface {
 	GetDefaultTTL() time.Duration
 }
 
+// EarlyExpiringCache is a TTL cache that can be told how long values take to compute, for WithEarlyExpiration
+type EarlyExpiringCache[K comparable, V any] interface {
+	TTLCache[K, V]
+	// SetWithRecomputeTime is SetWithTTL for a value that took recompute to compute
+	SetWithRecomputeTime(key K, value V, ttl, recompute time.Duration) error
+}
+
 type ttlCache[K comparable, V any] struct {
 	capacity   int
+	weight     int64
+	weigher    weigher[K, V]
+	weights    map[K]int64 // weight of each entry, only kept with a weigher
 	defaultTTL time.Duration
 	data       map[K]heap_item.Item[K, V]
-	keys       *priority_heap.MinHeap[K, V]
+	keys       expiryIndex[K, V]
+	resolution time.Duration // of the timing wheel, zero for the heap
 	mutex      sync.Mutex
+	clock      clock.Clock
+
+	sliding     bool
+	maxLifetime time.Duration
+	windows     map[K]slidingWindow // only kept with sliding expiration
+
+	refresh       *RefreshPolicy[K, V]
+	refreshes     map[K]*refreshState // only kept with a refresh policy
+	writes        uint64
+	refreshCtx    context.Context
+	cancelRefresh context.CancelFunc
+
+	beta      float64             // of early expiration, zero without it
+	recompute map[K]time.Duration // only kept with early expiration
+	jitter    ttlJitter
+	rand      *rand.Rand
+
+	events      *cache.EventDispatcher[K, V]
+	stopEvictor chan struct{}
+	evictorOnce sync.Once
+}
+
+// expiryIndex orders the entries of the TTL cache by deadline
+type expiryIndex[K comparable, V any] interface {
+	push(item heap_item.Item[K, V])
+	// fix moves an entry whose deadline changed
+	fix(item heap_item.Item[K, V])
+	remove(item heap_item.Item[K, V])
+	// popSoonest removes and returns the entry closest to its deadline, nil when the index is empty
+	popSoonest() heap_item.Item[K, V]
+	// popExpired removes and returns the entries whose deadline has been reached
+	popExpired(now time.Time) []heap_item.Item[K, V]
+	len() int
+}
+
+// deadlineHeap is the default expiry index: exact order, O(log n) updates
+type deadlineHeap[K comparable, V any] struct {
+	heap *priority_heap.MinHeap[K, V]
+}
+
+func (d deadlineHeap[K, V]) push(item heap_item.Item[K, V]) {
+	heap.Push(d.heap, item)
+}
+
+func (d deadlineHeap[K, V]) fix(item heap_item.Item[K, V]) {
+	heap.Fix(d.heap, item.GetIndex())
+}
 
-	eventCallbacks []func(cache.Event[K, V])
-	stopEvictor    chan struct{}
-	evictorOnce    sync.Once
+func (d deadlineHeap[K, V]) remove(item heap_item.Item[K, V]) {
+	if item.GetIndex() >= 0 {
+		heap.Remove(d.heap, item.GetIndex())
+	}
 }
 
-func newTtlCache[K comparable, V any](capacity int, defaultTTL time.Duration) cache.IterableCache[K, V] {
+func (d deadlineHeap[K, V]) popSoonest() heap_item.Item[K, V] {
+	if d.heap.Len() == 0 {
+		return nil
+	}
+	return heap.Pop(d.heap).(heap_item.Item[K, V])
+}
+
+func (d deadlineHeap[K, V]) popExpired(now time.Time) []heap_item.Item[K, V] {
+	var expired []heap_item.Item[K, V]
+	for {
+		top := d.heap.Peek()
+		if top == nil || now.Before(time.Unix(0, top.GetPriority())) {
+			return expired
+		}
+		expired = append(expired, heap.Pop(d.heap).(heap_item.Item[K, V]))
+	}
+}
+
+func (d deadlineHeap[K, V]) len() int {
+	return d.heap.Len()
+}
+
+// refreshState is what the refresh policy knows about an entry since its last write
+type refreshState struct {
+	ttl       time.Duration
+	refreshAt time.Time // when Get starts reloading the entry
+	expiresAt time.Time // end of freshness, the entry stays stored through the grace period after it
+	version   uint64    // number of the write, so a reload does not overwrite a newer value
+	loading   bool
+}
+
+// slidingWindow is what a Get needs to push the deadline of an entry forward
+type slidingWindow struct {
+	ttl    time.Duration
+	endsAt time.Time // end of the maximum lifetime, zero without one
+}
+
+// deadline returns when an entry accessed at now expires
+func (w slidingWindow) deadline(now time.Time) time.Time {
+	deadline := now.Add(w.ttl)
+	if !w.endsAt.IsZero() && w.endsAt.Before(deadline) {
+		return w.endsAt
+	}
+	return deadline
+}
+
+func newTtlCache[K comparable, V any](capacity int, defaultTTL time.Duration, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	cfg := newConfig(opts)
 	c := &ttlCache[K, V]{
 		capacity:   capacity,
+		weigher:    cfg.weigher,
 		defaultTTL: defaultTTL,
-		data:       make(map[K]heap_item.Item[K, V], capacity),
-		keys:       priority_heap.NewMinHeap[K, V](),
+		data:       make(map[K]heap_item.Item[K, V], cfg.weigher.sizeHint(capacity)),
+		resolution: cfg.wheelResolution,
+		clock:      cfg.timeSource(),
+		sliding:    cfg.sliding,
+		events:     cfg.dispatcher(),
+	}
+	if c.weigher != nil {
+		c.weights = make(map[K]int64)
+	}
+	if c.sliding {
+		c.maxLifetime = cfg.maxLifetime
+		c.windows = make(map[K]slidingWindow)
+	}
+	if cfg.refresh != nil && cfg.refresh.Loader != nil {
+		c.refresh = cfg.refresh
+		c.refreshes = make(map[K]*refreshState)
+		c.refreshCtx, c.cancelRefresh = context.WithCancel(context.Background())
+	}
+	if cfg.earlyExpirationBeta > 0 {
+		c.beta = cfg.earlyExpirationBeta
+		c.recompute = make(map[K]time.Duration)
+		c.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	c.jitter = newTTLJitter(cfg.ttlJitter)
+	c.keys = c.newIndex()
+	if c.resolution > 0 {
+		c.startEvictor(c.resolution)
+	} else {
+		c.startEvictor(defaultTTL / 2)
 	}
-	c.startEvictor(defaultTTL / 2)
 	return c
 }
 
+func (t *ttlCache[K, V]) newIndex() expiryIndex[K, V] {
+	if t.resolution > 0 {
+		return newTimingWheel[K, V](t.resolution, t.clock.Now())
+	}
+	return deadlineHeap[K, V]{heap: priority_heap.NewMinHeap[K, V]()}
+}
+
 func (t *ttlCache[K, V]) GetDefaultTTL() time.Duration {
 	return t.defaultTTL
 }
@@ -47,105 +192,354 @@ func (t *ttlCache[K, V]) GetDefaultTTL() time.Duration {
 func (t *ttlCache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
+	return t.set(key, value, ttl)
+}
+
+// SetWithRecomputeTime stores the value like SetWithTTL and records how long it took to compute,
+// so that with WithEarlyExpiration Gets start missing on it ahead of its deadline
+func (t *ttlCache[K, V]) SetWithRecomputeTime(key K, value V, ttl, recompute time.Duration) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 
-	if item, exists := t.data[key]; exists {
-		newExpiresAt := time.Now().Add(ttl)
-		item.SetPriority(newExpiresAt.UnixNano())
-		item.SetValue(value)
-		heap.Fix(t.keys, item.GetIndex())
+	if err := t.set(key, value, ttl); err != nil {
+		return err
+	}
+	t.recorded(key, recompute)
+	return nil
+}
+
+// recorded remembers how long the value of a stored key took to compute
+func (t *ttlCache[K, V]) recorded(key K, recompute time.Duration) {
+	if _, stored := t.data[key]; stored && t.recompute != nil {
+		t.recompute[key] = recompute
+	}
+}
+
+func (t *ttlCache[K, V]) set(key K, value V, ttl time.Duration) error {
+	weight, err := t.weigher.admit(key, value, t.capacity)
+	if err != nil {
+		return err
+	}
+	if t.update(key, value, weight, ttl) {
+		// The new value may weigh more than the old one
+		t.evict(0)
 		return nil
 	}
+	t.evict(weight)
+	t.insert(key, value, weight, ttl)
+	return nil
+}
 
-	if len(t.data) >= t.capacity {
-		popped := heap.Pop(t.keys)
-		if popped != nil {
-			evicted := popped.(heap_item.Item[K, V])
-			delete(t.data, evicted.GetKey())
-			t.emit(cache.Event[K, V]{
-				Type:  cache.EventTypeEviction,
-				Key:   evicted.GetKey(),
-				Value: evicted.GetValue(),
-			})
-		}
+// update overwrites the value of a present key
+func (t *ttlCache[K, V]) update(key K, value V, weight int64, ttl time.Duration) bool {
+	item, exists := t.data[key]
+	if !exists {
+		return false
+	}
+	t.rewrite(item, value, weight, ttl)
+	t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+	return true
+}
+
+// rewrite stores a new value of a present entry and restarts its ttl, and its maximum lifetime
+func (t *ttlCache[K, V]) rewrite(item heap_item.Item[K, V], value V, weight int64, ttl time.Duration) {
+	key := item.GetKey()
+	t.weight += weight - t.weightOf(key)
+	if t.weights != nil {
+		t.weights[key] = weight
 	}
+	item.SetPriority(t.written(key, ttl).UnixNano())
+	item.SetValue(value)
+	t.keys.fix(item)
+}
 
-	item := heap_item.NewTTLHeapItem(key, value, ttl)
+func (t *ttlCache[K, V]) insert(key K, value V, weight int64, ttl time.Duration) {
+	item := heap_item.NewTTLHeapItemAt(key, value, t.written(key, ttl))
 	t.data[key] = item
-	heap.Push(t.keys, item)
-	return nil
+	t.weight += weight
+	if t.weights != nil {
+		t.weights[key] = weight
+	}
+	t.keys.push(item)
+	t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+}
+
+// written returns the time an entry written now is stored until, starting its sliding window and its refresh
+// schedule if it has them
+func (t *ttlCache[K, V]) written(key K, ttl time.Duration) time.Time {
+	now := t.clock.Now()
+	jittered := t.jitter.apply(ttl)
+	deadline := now.Add(jittered)
+	if t.sliding {
+		window := slidingWindow{ttl: jittered}
+		if t.maxLifetime > 0 {
+			window.endsAt = now.Add(t.maxLifetime)
+		}
+		t.windows[key] = window
+		deadline = window.deadline(now)
+	}
+	if t.refresh == nil {
+		return deadline
+	}
+
+	t.writes++
+	state := &refreshState{ttl: ttl, refreshAt: deadline, version: t.writes}
+	if after := t.refresh.RefreshAfter; after > 0 && after < 1 {
+		state.refreshAt = now.Add(time.Duration(after * float64(jittered)))
+	}
+	t.refreshes[key] = state
+	return t.keepUntil(key, deadline)
+}
+
+// keepUntil sets the end of freshness of an entry and returns the time it is stored until, after its grace period
+func (t *ttlCache[K, V]) keepUntil(key K, deadline time.Time) time.Time {
+	state, exists := t.refreshes[key]
+	if !exists {
+		return deadline
+	}
+	state.expiresAt = deadline
+	return deadline.Add(t.refresh.Grace)
+}
+
+// freshUntil returns the end of freshness of an entry, which is when it expires unless it has a grace period
+func (t *ttlCache[K, V]) freshUntil(item heap_item.Item[K, V]) time.Time {
+	if state, exists := t.refreshes[item.GetKey()]; exists {
+		return state.expiresAt
+	}
+	return time.Unix(0, item.GetPriority())
+}
+
+func (t *ttlCache[K, V]) weightOf(key K) int64 {
+	if t.weights == nil {
+		return 1
+	}
+	return t.weights[key]
+}
+
+// forget drops an entry already taken out of the expiry index
+func (t *ttlCache[K, V]) forget(key K) {
+	delete(t.data, key)
+	t.weight -= t.weightOf(key)
+	delete(t.weights, key)
+	delete(t.windows, key)
+	delete(t.refreshes, key)
+	delete(t.recompute, key)
+}
+
+// evict removes entries, closest to expiration first, until weight more fits into the capacity.
+// Victims whose time had already run out are reported as expired rather than evicted for capacity.
+func (t *ttlCache[K, V]) evict(weight int64) {
+	now := t.clock.Now()
+	for t.weight+weight > int64(t.capacity) && t.keys.len() > 0 {
+		evicted := t.keys.popSoonest()
+		reason := cache.EvictionReasonCapacity
+		if !now.Before(t.freshUntil(evicted)) {
+			reason = cache.EvictionReasonExpired
+		}
+		t.forget(evicted.GetKey())
+
+		t.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    evicted.GetKey(),
+			Value:  evicted.GetValue(),
+			Reason: reason,
+		})
+	}
 }
 
 func (t *ttlCache[K, V]) Get(key K) (V, error) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
+	return t.get(key)
+}
 
+func (t *ttlCache[K, V]) get(key K) (V, error) {
 	item, exists := t.data[key]
 	if !exists {
+		t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
 		var zero V
 		return zero, common.ErrKeyNotFound
 	}
 
+	now := t.clock.Now()
 	expiresAt := time.Unix(0, item.GetPriority())
-	if time.Now().After(expiresAt) {
-		if item.GetIndex() >= 0 {
-			heap.Remove(t.keys, item.GetIndex())
-		}
-		delete(t.data, key)
-		t.emit(cache.Event[K, V]{
-			Type:  cache.EventTypeEviction,
-			Key:   item.GetKey(),
-			Value: item.GetValue(),
+	if !now.Before(expiresAt) {
+		t.keys.remove(item)
+		t.forget(key)
+		t.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    item.GetKey(),
+			Value:  item.GetValue(),
+			Reason: cache.EvictionReasonExpired,
 		})
+		t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+		var zero V
+		return zero, common.ErrKeyNotFound
+	}
+	if t.expiresEarly(item, now) {
+		t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
 		var zero V
 		return zero, common.ErrKeyNotFound
 	}
 
+	if window, ok := t.windows[key]; ok && now.Before(t.freshUntil(item)) {
+		item.SetPriority(t.keepUntil(key, window.deadline(now)).UnixNano())
+		t.keys.fix(item)
+	}
+	t.refreshIfDue(key, now)
+	t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: item.GetValue()})
 	return item.GetValue(), nil
 }
 
+// expiresEarly draws whether a Get treats a fresh entry as expired already, following XFetch: it does when
+// now - recompute·beta·ln(rand()) reaches the end of freshness of the entry
+func (t *ttlCache[K, V]) expiresEarly(item heap_item.Item[K, V], now time.Time) bool {
+	recompute, recorded := t.recompute[item.GetKey()]
+	if !recorded {
+		return false
+	}
+	freshUntil := t.freshUntil(item)
+	early := time.Duration(float64(recompute) * t.beta * -math.Log(1-t.rand.Float64()))
+	return now.Before(freshUntil) && !now.Add(early).Before(freshUntil)
+}
+
+// refreshIfDue starts reloading an entry in the background once its refresh point has passed
+func (t *ttlCache[K, V]) refreshIfDue(key K, now time.Time) {
+	state, exists := t.refreshes[key]
+	if !exists || state.loading || now.Before(state.refreshAt) {
+		return
+	}
+	state.loading = true
+	go t.reload(key, state.version)
+}
+
+// reload stores the value the loader returns for key, unless the entry was written or removed in the meantime
+func (t *ttlCache[K, V]) reload(key K, version uint64) {
+	start := t.clock.Now()
+	value, err := t.load(key)
+	recompute := t.clock.Now().Sub(start)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	state, exists := t.refreshes[key]
+	if !exists || state.version != version {
+		return
+	}
+	state.loading = false
+	item := t.data[key]
+	var weight int64
+	if err == nil {
+		weight, err = t.weigher.admit(key, value, t.capacity)
+	}
+	if err != nil {
+		t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeRefreshFailure, Key: key, Value: item.GetValue(), Err: err})
+		if !t.refresh.StaleIfError && !t.clock.Now().Before(state.expiresAt) {
+			t.keys.remove(item)
+			t.forget(key)
+			t.events.Emit(cache.Event[K, V]{
+				Type:   cache.EventTypeEviction,
+				Key:    key,
+				Value:  item.GetValue(),
+				Reason: cache.EvictionReasonExpired,
+			})
+		}
+		return
+	}
+
+	t.rewrite(item, value, weight, state.ttl)
+	t.recorded(key, recompute)
+	t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeRefresh, Key: key, Value: value})
+	// The new value may weigh more than the old one
+	t.evict(0)
+}
+
+// load runs the loader, turning a panic into an error since nobody waits on the goroutine to recover it
+func (t *ttlCache[K, V]) load(key K) (value V, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("refresh loader panicked: %v", r)
+		}
+	}()
+	return t.refresh.Loader(t.refreshCtx, key)
+}
+
 func (t *ttlCache[K, V]) Delete(key K) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
+	return t.delete(key)
+}
 
+func (t *ttlCache[K, V]) delete(key K) error {
 	item, exists := t.data[key]
 	if !exists {
 		return common.ErrKeyNotFound
 	}
 
-	if item.GetIndex() >= 0 {
-		heap.Remove(t.keys, item.GetIndex())
-	}
-	delete(t.data, key)
+	t.keys.remove(item)
+	t.forget(key)
+	t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: item.GetValue()})
 	return nil
 }
 
-func (t *ttlCache[K, V]) Clear() {
+func (t *ttlCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
-	t.data = make(map[K]heap_item.Item[K, V])
-	t.keys = priority_heap.NewMinHeap[K, V]()
+	return getMany(keys, t.get)
 }
 
-func (t *ttlCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) {
+// SetMany stores the entries with the default ttl, making room for all new keys in a single eviction pass.
+// If the new keys outweigh the capacity, only as many of them as fit are stored.
+func (t *ttlCache[K, V]) SetMany(entries map[K]V) error {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
-	t.eventCallbacks = append(t.eventCallbacks, callback)
+
+	weights, err := weighMany(entries, t.weigher, t.capacity)
+	fresh := freshKeys(entries, weights, func(key K, value V, weight int64) bool {
+		return t.update(key, value, weight, t.defaultTTL)
+	})
+	fresh, weight := fitting(fresh, weights, t.capacity)
+	t.evict(weight)
+	for _, key := range fresh {
+		t.insert(key, entries[key], weights[key], t.defaultTTL)
+	}
+	return err
 }
 
-func (t *ttlCache[K, V]) emit(event cache.Event[K, V]) {
-	for _, callback := range t.eventCallbacks {
-		callback(event)
+func (t *ttlCache[K, V]) DeleteMany(keys []K) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return deleteMany(keys, t.delete)
+}
+
+func (t *ttlCache[K, V]) Clear() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.data = make(map[K]heap_item.Item[K, V])
+	t.keys = t.newIndex()
+	t.weight = 0
+	if t.weigher != nil {
+		t.weights = make(map[K]int64)
+	}
+	if t.sliding {
+		t.windows = make(map[K]slidingWindow)
+	}
+	if t.refresh != nil {
+		t.refreshes = make(map[K]*refreshState)
+	}
+	if t.recompute != nil {
+		t.recompute = make(map[K]time.Duration)
 	}
+	t.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
 }
 
 func (t *ttlCache[K, V]) Range(f func(K, V) bool) {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
-	now := time.Now()
+	now := t.clock.Now()
 	for k, item := range t.data { // TODO: optimize
-		expiresAt := time.Unix(0, item.GetPriority())
-		if now.After(expiresAt) {
+		if !now.Before(t.freshUntil(item)) {
 			continue
 		}
 		if !f(k, item.GetValue()) {
@@ -157,18 +551,18 @@ func (t *ttlCache[K, V]) Range(f func(K, V) bool) {
 func (t *ttlCache[K, V]) startEvictor(interval time.Duration) {
 	t.evictorOnce.Do(func() {
 		t.stopEvictor = make(chan struct{})
-		go func() {
-			ticker := time.NewTicker(interval)
+		ticker := t.clock.NewTicker(interval)
+		go func(stop <-chan struct{}) {
 			defer ticker.Stop()
 			for {
 				select {
-				case <-ticker.C:
+				case <-ticker.C():
 					t.evictExpired()
-				case <-t.stopEvictor:
+				case <-stop:
 					return
 				}
 			}
-		}()
+		}(t.stopEvictor)
 	})
 }
 
@@ -176,35 +570,38 @@ func (t *ttlCache[K, V]) evictExpired() {
 	t.mutex.Lock()
 	defer t.mutex.Unlock()
 
-	now := time.Now()
-	for {
-		top := t.keys.Peek()
-		if top == nil {
-			break
-		}
-
-		expiresAt := time.Unix(0, top.GetPriority())
-		if now.Before(expiresAt) {
-			break
-		}
-
-		item := heap.Pop(t.keys).(heap_item.Item[K, V])
-		delete(t.data, item.GetKey())
-		t.emit(cache.Event[K, V]{
-			Type:  cache.EventTypeEviction,
-			Key:   item.GetKey(),
-			Value: item.GetValue(),
+	for _, item := range t.keys.popExpired(t.clock.Now()) {
+		t.forget(item.GetKey())
+		t.events.Emit(cache.Event[K, V]{
+			Type:   cache.EventTypeEviction,
+			Key:    item.GetKey(),
+			Value:  item.GetValue(),
+			Reason: cache.EvictionReasonExpired,
 		})
 	}
 }
 
+func (t *ttlCache[K, V]) Weight() int64 {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.weight
+}
+
+// Stop terminates the background evictor and cancels the context of running reloads
 func (t *ttlCache[K, V]) Stop() {
 	if t.stopEvictor != nil {
 		close(t.stopEvictor)
 		t.stopEvictor = nil
 	}
+	if t.cancelRefresh != nil {
+		t.cancelRefresh()
+	}
 }
 
 func (t *ttlCache[K, V]) Set(key K, value V) error {
 	return t.SetWithTTL(key, value, t.defaultTTL)
 }
+
+func (t *ttlCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return t.events.Subscribe(callback)
+}
@@ -1,7 +1,15 @@
 package strategies
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/clock/clocktest"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"math"
+	"math/rand"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -9,9 +17,16 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// newFakeTtlCache builds a TTL cache on a fake clock, so tests advance time instead of sleeping
+func newFakeTtlCache[V any](capacity int, ttl time.Duration, opts ...Option[string, V]) (cache.IterableCache[string, V], *clocktest.FakeClock) {
+	clk := clocktest.NewFakeClock(time.Unix(0, 0))
+	c := NewTtlCache[string, V](capacity, ttl, append(opts, WithClock[string, V](clk))...)()
+	return c, clk
+}
+
 // TestTTLCache tests the NewTtlCache cache implementation with default NewTtlCache
 func TestTTLCache(t *testing.T) {
-	c := NewTtlCache[string, int](3, 100*time.Millisecond)()
+	c, clk := newFakeTtlCache[int](3, 100*time.Millisecond)
 
 	// Test basic operations
 	err := c.Set("a", 1)
@@ -24,7 +39,7 @@ func TestTTLCache(t *testing.T) {
 	assert.Equal(t, 1, val)
 
 	// Wait for expiration
-	time.Sleep(150 * time.Millisecond)
+	clk.Advance(150 * time.Millisecond)
 
 	// Should not find expired entries
 	_, err = c.Get("a")
@@ -43,7 +58,7 @@ func TestTTLCache(t *testing.T) {
 
 // TestTTLCacheWithCustomTTL tests individual NewTtlCache per item
 func TestTTLCacheWithCustomTTL(t *testing.T) {
-	c := NewTtlCache[string, string](10, 500*time.Millisecond)()
+	c, clk := newFakeTtlCache[string](10, 500*time.Millisecond)
 
 	// Type assert to TTLCache interface
 	ttlCache, ok := c.(TTLCache[string, string])
@@ -73,7 +88,7 @@ func TestTTLCacheWithCustomTTL(t *testing.T) {
 	assert.Equal(t, "expires in 1s", val)
 
 	// Wait 150ms - short should expire
-	time.Sleep(150 * time.Millisecond)
+	clk.Advance(150 * time.Millisecond)
 
 	_, err = c.Get("short")
 	assert.Error(t, err, "short-lived item should be expired")
@@ -87,7 +102,7 @@ func TestTTLCacheWithCustomTTL(t *testing.T) {
 	assert.Equal(t, "expires in 1s", val)
 
 	// Wait another 400ms (total 550ms) - default should expire
-	time.Sleep(400 * time.Millisecond)
+	clk.Advance(400 * time.Millisecond)
 
 	_, err = c.Get("default")
 	assert.Error(t, err, "default NewTtlCache item should be expired")
@@ -97,7 +112,7 @@ func TestTTLCacheWithCustomTTL(t *testing.T) {
 	assert.Equal(t, "expires in 1s", val)
 
 	// Wait another 500ms (total 1050ms) - all should expire
-	time.Sleep(500 * time.Millisecond)
+	clk.Advance(500 * time.Millisecond)
 
 	_, err = c.Get("long")
 	assert.Error(t, err, "long-lived item should be expired")
@@ -105,7 +120,7 @@ func TestTTLCacheWithCustomTTL(t *testing.T) {
 
 // TestTTLCacheUpdateWithCustomTTL tests updating existing items with new NewTtlCache
 func TestTTLCacheUpdateWithCustomTTL(t *testing.T) {
-	c := NewTtlCache[string, int](10, 1*time.Second)()
+	c, clk := newFakeTtlCache[int](10, 1*time.Second)
 	ttlCache := c.(TTLCache[string, int])
 
 	// Add item with short NewTtlCache
@@ -113,20 +128,20 @@ func TestTTLCacheUpdateWithCustomTTL(t *testing.T) {
 	require.NoError(t, err)
 
 	// Wait 50ms, then update with longer NewTtlCache
-	time.Sleep(50 * time.Millisecond)
+	clk.Advance(50 * time.Millisecond)
 	err = ttlCache.SetWithTTL("key", 2, 500*time.Millisecond)
 	require.NoError(t, err)
 
 	// Wait another 100ms (total 150ms from original)
 	// Original would have expired, but new NewTtlCache keeps it alive
-	time.Sleep(100 * time.Millisecond)
+	clk.Advance(100 * time.Millisecond)
 
 	val, err := c.Get("key")
 	require.NoError(t, err, "item should still be alive with extended NewTtlCache")
 	assert.Equal(t, 2, val, "value should be updated")
 
 	// Wait until new NewTtlCache expires
-	time.Sleep(400 * time.Millisecond)
+	clk.Advance(400 * time.Millisecond)
 
 	_, err = c.Get("key")
 	assert.Error(t, err, "item should be expired after new NewTtlCache")
@@ -134,7 +149,7 @@ func TestTTLCacheUpdateWithCustomTTL(t *testing.T) {
 
 // TestTTLCacheMixedOperations tests mixing Set and SetWithTTL
 func TestTTLCacheMixedOperations(t *testing.T) {
-	c := NewTtlCache[string, string](5, 200*time.Millisecond)()
+	c, clk := newFakeTtlCache[string](5, 200*time.Millisecond)
 	ttlCache := c.(TTLCache[string, string])
 
 	// Mix of default and custom TTLs
@@ -150,7 +165,7 @@ func TestTTLCacheMixedOperations(t *testing.T) {
 	}
 
 	// Wait 100ms - only 'b' should expire
-	time.Sleep(100 * time.Millisecond)
+	clk.Advance(100 * time.Millisecond)
 
 	_, err := c.Get("b")
 	assert.Error(t, err, "b should be expired")
@@ -161,7 +176,7 @@ func TestTTLCacheMixedOperations(t *testing.T) {
 	}
 
 	// Wait another 150ms (total 250ms) - a and d should expire
-	time.Sleep(150 * time.Millisecond)
+	clk.Advance(150 * time.Millisecond)
 
 	_, err = c.Get("a")
 	assert.Error(t, err, "a should be expired")
@@ -176,16 +191,14 @@ func TestTTLCacheMixedOperations(t *testing.T) {
 
 // TestTTLCacheEvictionEvents tests that eviction events are fired
 func TestTTLCacheEvictionEvents(t *testing.T) {
-	c := NewTtlCache[string, int](3, 100*time.Millisecond)()
+	c, clk := newFakeTtlCache[int](3, 100*time.Millisecond)
 	ttlCache := c.(TTLCache[string, int])
 
-	evictions := 0
-	if observable, ok := c.(interface {
-		OnEvent(func(event cache.Event[string, int]))
-	}); ok {
+	var evictions atomic.Int64
+	if observable, ok := c.(cache.ObservableCache[string, int]); ok {
 		observable.OnEvent(func(event cache.Event[string, int]) {
 			if event.Type == cache.EventTypeEviction {
-				evictions++
+				evictions.Add(1)
 			}
 		})
 	}
@@ -196,22 +209,22 @@ func TestTTLCacheEvictionEvents(t *testing.T) {
 	_ = ttlCache.SetWithTTL("c", 3, 250*time.Millisecond)
 
 	// Wait for first expiration
-	time.Sleep(80 * time.Millisecond)
+	clk.Advance(80 * time.Millisecond)
 	_, _ = c.Get("a") // Trigger eviction check
 
-	assert.Equal(t, 1, evictions, "one item should be evicted")
+	assert.Equal(t, int64(1), evictions.Load(), "one item should be evicted")
 
 	// Wait for second expiration
-	time.Sleep(100 * time.Millisecond)
+	clk.Advance(100 * time.Millisecond)
 	_, _ = c.Get("b") // Trigger eviction check
 
-	assert.Equal(t, 2, evictions, "two items should be evicted")
+	assert.Equal(t, int64(2), evictions.Load(), "two items should be evicted")
 
 	// Wait for third expiration
-	time.Sleep(100 * time.Millisecond)
+	clk.Advance(100 * time.Millisecond)
 	_, _ = c.Get("c") // Trigger eviction check
 
-	assert.Equal(t, 3, evictions, "all items should be evicted")
+	assert.Equal(t, int64(3), evictions.Load(), "all items should be evicted")
 }
 
 // TestTTLCacheCapacityWithCustomTTL tests capacity limits with different TTLs
@@ -249,3 +262,259 @@ func TestTTLCacheZeroTTL(t *testing.T) {
 	_, err = c.Get("instant")
 	assert.Error(t, err, "item with zero NewTtlCache should be immediately expired")
 }
+
+// TestTTLCacheSlidingExpiration tests that Get keeps an entry alive while it is used within its ttl
+func TestTTLCacheSlidingExpiration(t *testing.T) {
+	c, clk := newFakeTtlCache[int](5, time.Minute, WithSlidingExpiration[string, int](0))
+
+	require.NoError(t, c.Set("active", 1))
+	require.NoError(t, c.Set("idle", 2))
+	for i := 0; i < 5; i++ {
+		clk.Advance(40 * time.Second)
+		_, err := c.Get("active")
+		require.NoError(t, err, "active entry should still be there after %d accesses", i)
+	}
+
+	_, err := c.Get("idle")
+	assert.ErrorIs(t, err, common.ErrKeyNotFound, "idle entry should expire a minute after it was written")
+
+	clk.Advance(61 * time.Second)
+	_, err = c.Get("active")
+	assert.ErrorIs(t, err, common.ErrKeyNotFound, "active entry should expire a minute after its last access")
+}
+
+// TestTTLCacheSlidingExpirationMaxLifetime tests that accesses cannot keep an entry alive past its maximum lifetime,
+// and that a write starts a new one
+func TestTTLCacheSlidingExpirationMaxLifetime(t *testing.T) {
+	c, clk := newFakeTtlCache[int](5, time.Minute, WithSlidingExpiration[string, int](90*time.Second))
+
+	require.NoError(t, c.Set("a", 1))
+	clk.Advance(50 * time.Second)
+	_, err := c.Get("a")
+	require.NoError(t, err)
+	clk.Advance(39 * time.Second)
+	_, err = c.Get("a")
+	require.NoError(t, err)
+
+	// The access pushed the deadline to 89s + 1m, but the lifetime ends at 90s
+	clk.Advance(2 * time.Second)
+	_, err = c.Get("a")
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+
+	require.NoError(t, c.Set("a", 1))
+	clk.Advance(50 * time.Second)
+	require.NoError(t, c.(TTLCache[string, int]).SetWithTTL("a", 2, 20*time.Second))
+	clk.Advance(15 * time.Second)
+	val, err := c.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, val)
+	clk.Advance(21 * time.Second)
+	_, err = c.Get("a")
+	assert.ErrorIs(t, err, common.ErrKeyNotFound, "the ttl of the last write should apply to accesses")
+}
+
+// refreshEvents forwards the refresh events of c to a channel, so tests can wait for background reloads
+func refreshEvents(c cache.IterableCache[string, int]) <-chan cache.Event[string, int] {
+	events := make(chan cache.Event[string, int], 16)
+	c.(cache.ObservableCache[string, int]).OnEvent(func(event cache.Event[string, int]) {
+		if event.Type == cache.EventTypeRefresh || event.Type == cache.EventTypeRefreshFailure {
+			events <- event
+		}
+	})
+	return events
+}
+
+// TestTTLCacheRefreshAhead tests that a Get past the refresh point returns the cached value and starts a single reload
+func TestTTLCacheRefreshAhead(t *testing.T) {
+	release := make(chan struct{})
+	var loads atomic.Int64
+	c, clk := newFakeTtlCache[int](5, time.Minute, WithRefresh(RefreshPolicy[string, int]{
+		Loader: func(_ context.Context, key string) (int, error) {
+			<-release
+			return int(loads.Add(1)) + 1, nil
+		},
+		RefreshAfter: 0.5,
+	}))
+	defer c.(interface{ Stop() }).Stop()
+	refreshes := refreshEvents(c)
+
+	require.NoError(t, c.Set("a", 1))
+	clk.Advance(20 * time.Second)
+	val, err := c.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, 1, val)
+
+	clk.Advance(20 * time.Second)
+	for i := 0; i < 3; i++ {
+		val, err = c.Get("a")
+		require.NoError(t, err)
+		assert.Equal(t, 1, val, "Get should not wait for the reload")
+	}
+	close(release)
+	event := <-refreshes
+	assert.Equal(t, cache.EventTypeRefresh, event.Type)
+	assert.Equal(t, 2, event.Value)
+	assert.Equal(t, int64(1), loads.Load(), "concurrent Gets should share a reload")
+
+	// The reload restarted the ttl, and the refresh schedule
+	clk.Advance(50 * time.Second)
+	val, err = c.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, 2, val)
+	assert.Equal(t, cache.EventTypeRefresh, (<-refreshes).Type)
+}
+
+// TestTTLCacheRefreshLosesToSet tests that a reload does not overwrite a value set while it was running
+func TestTTLCacheRefreshLosesToSet(t *testing.T) {
+	release := make(chan struct{})
+	done := make(chan struct{})
+	c, clk := newFakeTtlCache[int](5, time.Minute, WithRefresh(RefreshPolicy[string, int]{
+		Loader: func(context.Context, string) (int, error) {
+			defer close(done)
+			<-release
+			return 2, nil
+		},
+		RefreshAfter: 0.5,
+	}))
+	defer c.(interface{ Stop() }).Stop()
+
+	require.NoError(t, c.Set("a", 1))
+	clk.Advance(40 * time.Second)
+	_, err := c.Get("a")
+	require.NoError(t, err)
+	require.NoError(t, c.Set("a", 3))
+	close(release)
+	<-done
+
+	val, err := c.Get("a")
+	require.NoError(t, err)
+	assert.Equal(t, 3, val)
+}
+
+// TestTTLCacheStaleWhileRevalidate tests that an expired entry is served stale during its grace period
+// while it is reloaded, and what a failed reload does to it with and without stale-if-error
+func TestTTLCacheStaleWhileRevalidate(t *testing.T) {
+	errLoad := errors.New("database down")
+	for _, staleIfError := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stale if error %v", staleIfError), func(t *testing.T) {
+			var fail atomic.Bool
+			fail.Store(true)
+			c, clk := newFakeTtlCache[int](5, time.Minute, WithRefresh(RefreshPolicy[string, int]{
+				Loader: func(context.Context, string) (int, error) {
+					if fail.Load() {
+						return 0, errLoad
+					}
+					return 2, nil
+				},
+				Grace:        30 * time.Second,
+				StaleIfError: staleIfError,
+			}))
+			defer c.(interface{ Stop() }).Stop()
+			refreshes := refreshEvents(c)
+
+			require.NoError(t, c.Set("a", 1))
+			clk.Advance(70 * time.Second)
+			c.Range(func(key string, _ int) bool {
+				t.Errorf("Range should skip stale entry %s", key)
+				return true
+			})
+			val, err := c.Get("a")
+			require.NoError(t, err, "an expired entry should be served during its grace period")
+			assert.Equal(t, 1, val)
+
+			event := <-refreshes
+			assert.Equal(t, cache.EventTypeRefreshFailure, event.Type)
+			assert.ErrorIs(t, event.Err, errLoad)
+			assert.Equal(t, 1, event.Value)
+
+			val, err = c.Get("a")
+			if !staleIfError {
+				assert.ErrorIs(t, err, common.ErrKeyNotFound, "a failed reload should drop the stale entry")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, 1, val)
+			assert.Equal(t, cache.EventTypeRefreshFailure, (<-refreshes).Type)
+
+			fail.Store(false)
+			_, err = c.Get("a")
+			require.NoError(t, err)
+			assert.Equal(t, cache.EventTypeRefresh, (<-refreshes).Type)
+			val, err = c.Get("a")
+			require.NoError(t, err)
+			assert.Equal(t, 2, val)
+		})
+	}
+}
+
+// TestTTLCacheStaleGraceEnds tests that nothing is served past the grace period
+func TestTTLCacheStaleGraceEnds(t *testing.T) {
+	c, clk := newFakeTtlCache[int](5, time.Minute, WithRefresh(RefreshPolicy[string, int]{
+		Loader: func(context.Context, string) (int, error) { return 2, nil },
+		Grace:  30 * time.Second,
+	}))
+	defer c.(interface{ Stop() }).Stop()
+
+	require.NoError(t, c.Set("a", 1))
+	clk.Advance(90 * time.Second)
+	_, err := c.Get("a")
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+}
+
+// TestTTLCacheEarlyExpiration tests that Gets miss early with the probability XFetch gives,
+// exp(-remaining / (recompute·beta)), and leave the entry in place
+func TestTTLCacheEarlyExpiration(t *testing.T) {
+	c, clk := newFakeTtlCache[int](5, time.Minute, WithEarlyExpiration[string, int](1))
+	ttl := c.(*ttlCache[string, int])
+	ttl.rand = rand.New(rand.NewSource(1))
+	events := make(map[cache.EventType]int)
+	c.(cache.ObservableCache[string, int]).OnEvent(func(event cache.Event[string, int]) {
+		events[event.Type]++
+	})
+
+	require.NoError(t, ttl.SetWithRecomputeTime("slow", 1, time.Minute, 10*time.Second))
+	require.NoError(t, c.Set("unknown", 2))
+	missRate := func(key string) float64 {
+		misses := 0
+		for i := 0; i < 2000; i++ {
+			if _, err := c.Get(key); err != nil {
+				misses++
+			}
+		}
+		return float64(misses) / 2000
+	}
+
+	clk.Advance(10 * time.Second)
+	assert.InDelta(t, math.Exp(-5), missRate("slow"), 0.01, "50s before the deadline")
+	clk.Advance(40 * time.Second)
+	assert.InDelta(t, math.Exp(-1), missRate("slow"), 0.05, "10s before the deadline")
+	clk.Advance(9 * time.Second)
+	assert.InDelta(t, math.Exp(-0.1), missRate("slow"), 0.05, "1s before the deadline")
+	assert.Zero(t, missRate("unknown"), "entries without a recompute time should never expire early")
+
+	assert.Zero(t, events[cache.EventTypeEviction], "early misses should leave entries in place")
+	assert.Equal(t, 2, ttl.keys.len())
+}
+
+// TestTTLCacheJitter tests that ttls are spread within the jitter fraction either way
+func TestTTLCacheJitter(t *testing.T) {
+	c, clk := newFakeTtlCache[int](1000, 100*time.Second, WithTTLJitter[string, int](0.1))
+	for i := 0; i < 500; i++ {
+		require.NoError(t, c.Set(fmt.Sprint(i), i))
+	}
+	alive := func() int {
+		n := 0
+		c.Range(func(string, int) bool {
+			n++
+			return true
+		})
+		return n
+	}
+
+	clk.Advance(89 * time.Second)
+	assert.Equal(t, 500, alive())
+	clk.Advance(11 * time.Second)
+	assert.InDelta(t, 250, alive(), 60, "about half the entries should be gone at the nominal ttl")
+	clk.Advance(11 * time.Second)
+	assert.Zero(t, alive())
+}
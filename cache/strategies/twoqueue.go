@@ -0,0 +1,218 @@
+package strategies
+
+import (
+	"errors"
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"sync"
+)
+
+const (
+	twoQueueInPercent  = 25 // share of the capacity given to A1in
+	twoQueueOutPercent = 50 // number of A1out ghosts, relative to the capacity
+)
+
+// twoQueueCache implements the full version of 2Q.
+// New entries enter A1in, a FIFO queue that absorbs correlated references; entries leaving it are remembered
+// in the A1out ghost queue. Only a key that comes back while it is still remembered enters Am, the main LRU queue,
+// so entries referenced once, like a scan, never reach it.
+type twoQueueCache[K comparable, V any] struct {
+	capacity int
+	inCap    int64
+	outCap   int64
+	weigher  weigher[K, V]
+
+	a1in  *cacheList[K, V] // newest at the front
+	a1out *cacheList[K, V]
+	am    *cacheList[K, V] // most recently used at the front
+	mu    sync.Locker
+
+	events *cache.EventDispatcher[K, V]
+}
+
+func newTwoQueueCache[K comparable, V any](capacity int, opts ...Option[K, V]) cache.IterableCache[K, V] {
+	capacity = max(capacity, 1)
+	cfg := newConfig(opts)
+	return &twoQueueCache[K, V]{
+		capacity: capacity,
+		inCap:    int64(max(capacity*twoQueueInPercent/100, 1)),
+		outCap:   int64(max(capacity*twoQueueOutPercent/100, 1)),
+		weigher:  cfg.weigher,
+		a1in:     newCacheList[K, V](false),
+		a1out:    newCacheList[K, V](true),
+		am:       newCacheList[K, V](false),
+		mu:       cfg.locker(),
+		events:   cfg.dispatcher(),
+	}
+}
+
+func (q *twoQueueCache[K, V]) Get(key K) (V, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.get(key)
+}
+
+func (q *twoQueueCache[K, V]) get(key K) (V, error) {
+	if elem, ok := q.am.m[key]; ok {
+		q.am.l.MoveToFront(elem)
+		value := elem.Value.(*entry[K, V]).value
+		q.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: value})
+		return value, nil
+	}
+	// A hit in A1in is likely a correlated reference, it does not change the entry's position
+	if elem, ok := q.a1in.m[key]; ok {
+		value := elem.Value.(*entry[K, V]).value
+		q.events.Emit(cache.Event[K, V]{Type: cache.EventTypeHit, Key: key, Value: value})
+		return value, nil
+	}
+
+	q.events.Emit(cache.Event[K, V]{Type: cache.EventTypeMiss, Key: key})
+	var zero V
+	return zero, common.ErrKeyNotFound
+}
+
+func (q *twoQueueCache[K, V]) Set(key K, value V) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.set(key, value)
+}
+
+func (q *twoQueueCache[K, V]) set(key K, value V) error {
+	weight, err := q.weigher.admit(key, value, q.capacity)
+	if err != nil {
+		return err
+	}
+	switch {
+	case q.am.m[key] != nil:
+		q.am.moveToFront(key, value, weight)
+		q.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+		// The new value may weigh more than the old one
+		q.reclaim(0)
+		return nil
+	case q.a1in.m[key] != nil:
+		q.a1in.update(key, value, weight)
+		q.events.Emit(cache.Event[K, V]{Type: cache.EventTypeUpdate, Key: key, Value: value})
+		q.reclaim(0)
+		return nil
+	}
+
+	q.reclaim(weight)
+	if q.a1out.m[key] != nil {
+		q.a1out.remove(key)
+		q.am.addFront(key, value, weight)
+	} else {
+		q.a1in.addFront(key, value, weight)
+	}
+	q.events.Emit(cache.Event[K, V]{Type: cache.EventTypeInsert, Key: key, Value: value})
+	return nil
+}
+
+// reclaim evicts entries until weight more fits into the capacity: the oldest A1in entries, remembered in A1out,
+// while A1in is over its share, otherwise the least recently used Am entries
+func (q *twoQueueCache[K, V]) reclaim(weight int64) {
+	for q.a1in.size+q.am.size+weight > int64(q.capacity) && q.a1in.len()+q.am.len() > 0 {
+		q.reclaimOne()
+	}
+}
+
+func (q *twoQueueCache[K, V]) reclaimOne() {
+	var evicted *entry[K, V]
+	if q.a1in.size > q.inCap || (q.am.len() == 0 && q.a1in.len() > 0) {
+		evicted = q.a1in.l.Back().Value.(*entry[K, V])
+		q.a1in.remove(evicted.key)
+		q.a1out.addFront(evicted.key, evicted.value, evicted.weight)
+		for q.a1out.size > q.outCap {
+			q.a1out.removeOldest()
+		}
+	} else {
+		evicted = q.am.l.Back().Value.(*entry[K, V])
+		q.am.remove(evicted.key)
+	}
+
+	q.events.Emit(cache.Event[K, V]{
+		Type:   cache.EventTypeEviction,
+		Key:    evicted.key,
+		Value:  evicted.value,
+		Reason: cache.EvictionReasonCapacity,
+	})
+}
+
+func (q *twoQueueCache[K, V]) Delete(key K) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.delete(key)
+}
+
+func (q *twoQueueCache[K, V]) delete(key K) error {
+	for _, queue := range []*cacheList[K, V]{q.a1in, q.am} {
+		if elem, ok := queue.m[key]; ok {
+			queue.remove(key)
+			q.events.Emit(cache.Event[K, V]{Type: cache.EventTypeDelete, Key: key, Value: elem.Value.(*entry[K, V]).value})
+			return nil
+		}
+	}
+	return common.ErrKeyNotFound
+}
+
+func (q *twoQueueCache[K, V]) GetMany(keys []K) (map[K]V, []K) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return getMany(keys, q.get)
+}
+
+// SetMany stores the entries under a single lock acquisition.
+// Like ARC it replaces victims one entry at a time, since a ghost hit changes the queue the next victim comes from.
+func (q *twoQueueCache[K, V]) SetMany(entries map[K]V) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var errs []error
+	for key, value := range entries {
+		if err := q.set(key, value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (q *twoQueueCache[K, V]) DeleteMany(keys []K) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return deleteMany(keys, q.delete)
+}
+
+func (q *twoQueueCache[K, V]) Clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.a1in = newCacheList[K, V](false)
+	q.a1out = newCacheList[K, V](true)
+	q.am = newCacheList[K, V](false)
+	q.events.Emit(cache.Event[K, V]{Type: cache.EventTypeClear})
+}
+
+// Range visits A1in, then Am, each from the newest or most recently used entry
+func (q *twoQueueCache[K, V]) Range(fn func(K, V) bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, queue := range []*cacheList[K, V]{q.a1in, q.am} {
+		for elem := queue.l.Front(); elem != nil; elem = elem.Next() {
+			e := elem.Value.(*entry[K, V])
+			if !fn(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+func (q *twoQueueCache[K, V]) Weight() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.a1in.size + q.am.size
+}
+
+func (q *twoQueueCache[K, V]) OnEvent(callback func(event cache.Event[K, V])) cache.Subscription {
+	return q.events.Subscribe(callback)
+}
@@ -0,0 +1,42 @@
+package strategies
+
+import (
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies/common"
+)
+
+// WeightedCache is implemented by every cache built by this package
+type WeightedCache[K comparable, V any] interface {
+	cache.IterableCache[K, V]
+	// Weight returns the total weight of the cached entries, which is their number unless built with WithWeigher
+	Weight() int64
+}
+
+// weigher measures entries against the capacity of a cache.
+// A nil weigher gives every entry a weight of 1, so the capacity counts entries.
+type weigher[K comparable, V any] func(key K, value V) int64
+
+func (w weigher[K, V]) weigh(key K, value V) int64 {
+	if w == nil {
+		return 1
+	}
+	return max(w(key, value), 0)
+}
+
+// admit weighs an entry about to be stored and rejects it if it would not fit even into an empty cache
+func (w weigher[K, V]) admit(key K, value V, capacity int) (int64, error) {
+	weight := w.weigh(key, value)
+	if w != nil && weight > int64(capacity) {
+		return 0, &common.EntryTooLargeError{Key: key, Weight: weight, Capacity: int64(capacity)}
+	}
+	return weight, nil
+}
+
+// sizeHint is the number of entries worth allocating room for up front:
+// the capacity, unless it is a budget of weight that says nothing about the number of entries
+func (w weigher[K, V]) sizeHint(capacity int) int {
+	if w != nil {
+		return 0
+	}
+	return max(capacity, 0)
+}
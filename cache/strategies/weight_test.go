@@ -0,0 +1,234 @@
+package strategies_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kimvlry/caching/cache"
+	"github.com/kimvlry/caching/cache/strategies"
+	"github.com/kimvlry/caching/cache/strategies/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func weightedFactories(capacity int, opts ...strategies.Option[int, string]) map[string]strategies.CacheFactory[int, string] {
+	return map[string]strategies.CacheFactory[int, string]{
+		"lru":      strategies.NewLruCache[int, string](capacity, opts...),
+		"lfu":      strategies.NewLfuCache[int, string](capacity, opts...),
+		"fifo":     strategies.NewFifoCache[int, string](capacity, opts...),
+		"arc":      strategies.NewArcCache[int, string](capacity, opts...),
+		"tinylfu":  strategies.NewTinyLfuCache[int, string](capacity, opts...),
+		"sieve":    strategies.NewSieveCache[int, string](capacity, opts...),
+		"s3fifo":   strategies.NewS3FifoCache[int, string](capacity, opts...),
+		"clock":    strategies.NewClockCache[int, string](capacity, opts...),
+		"clockpro": strategies.NewClockProCache[int, string](capacity, opts...),
+		"slru":     strategies.NewSlruCache[int, string](capacity, 0.8, opts...),
+		"2q":       strategies.NewTwoQueueCache[int, string](capacity, opts...),
+		"lirs":     strategies.NewLirsCache[int, string](capacity, 0.1, opts...),
+		"gdsf":     strategies.NewGdsfCache[int, string](capacity, nil, opts...),
+		"car":      strategies.NewCarCache[int, string](capacity, opts...),
+		"sampled":  strategies.NewSampledCache[int, string](capacity, 5, strategies.LowestFrequency[int, string], opts...),
+		"ttl":      strategies.NewTtlCache[int, string](capacity, time.Minute, opts...),
+		"ttlwheel": strategies.NewTtlCache[int, string](capacity, time.Minute,
+			append(opts, strategies.WithTimingWheel[int, string](time.Second))...,
+		),
+	}
+}
+
+func byLength() strategies.Option[int, string] {
+	return strategies.WithWeigher(func(_ int, value string) int64 { return int64(len(value)) })
+}
+
+// storedWeight adds up the weight of the entries Range visits
+func storedWeight(c cache.IterableCache[int, string]) int64 {
+	var total int64
+	c.Range(func(_ int, value string) bool {
+		total += int64(len(value))
+		return true
+	})
+	return total
+}
+
+// TestWeigherBoundsWeight tests that a random mix of operations never lets the stored weight exceed the capacity
+func TestWeigherBoundsWeight(t *testing.T) {
+	const capacity = 100
+
+	for name, factory := range weightedFactories(capacity, byLength()) {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+			weighted := c.(strategies.WeightedCache[int, string])
+			rng := rand.New(rand.NewSource(1))
+
+			for i := 0; i < 5000; i++ {
+				key := rng.Intn(50)
+				value := strings.Repeat("x", 1+rng.Intn(30))
+				switch rng.Intn(10) {
+				case 0:
+					_ = c.Delete(key)
+				case 1:
+					require.NoError(t, c.(cache.BatchCache[int, string]).SetMany(map[int]string{key: value, key + 50: value}))
+				case 2, 3, 4:
+					_, _ = c.Get(key)
+				default:
+					require.NoError(t, c.Set(key, value))
+				}
+
+				weight := weighted.Weight()
+				require.LessOrEqual(t, weight, int64(capacity))
+				require.Equal(t, storedWeight(c), weight)
+			}
+		})
+	}
+}
+
+// TestRandomInvariants tests, after every operation of a random mix on small capacities, that Range visits each key
+// once and exactly the entries the events report as stored, and that Weight adds up their weight within the capacity
+func TestRandomInvariants(t *testing.T) {
+	for capacity := 1; capacity <= 3; capacity++ {
+		for _, weighted := range []bool{false, true} {
+			var opts []strategies.Option[int, string]
+			if weighted {
+				opts = append(opts, byLength())
+			}
+			for name, factory := range weightedFactories(capacity, opts...) {
+				t.Run(fmt.Sprintf("%s/%d/weighted=%t", name, capacity, weighted), func(t *testing.T) {
+					c := factory()
+					model := map[int]string{}
+					c.(cache.ObservableCache[int, string]).OnEvent(func(event cache.Event[int, string]) {
+						switch event.Type {
+						case cache.EventTypeInsert, cache.EventTypeUpdate:
+							model[event.Key] = event.Value
+						case cache.EventTypeEviction, cache.EventTypeDelete:
+							delete(model, event.Key)
+						case cache.EventTypeClear:
+							clear(model)
+						}
+					})
+					batch := c.(cache.BatchCache[int, string])
+					rng := rand.New(rand.NewSource(int64(capacity)))
+
+					for i := 0; i < 3000; i++ {
+						key := rng.Intn(6)
+						value := strings.Repeat("x", 1+rng.Intn(capacity))
+						switch rng.Intn(20) {
+						case 0:
+							c.Clear()
+						case 1, 2:
+							_ = c.Delete(key)
+						case 3:
+							batch.DeleteMany([]int{key, key + 1})
+						case 4:
+							require.NoError(t, batch.SetMany(map[int]string{key: value, key + 1: value}))
+						case 5, 6, 7, 8, 9, 10:
+							_, _ = c.Get(key)
+						default:
+							require.NoError(t, c.Set(key, value))
+						}
+
+						stored := map[int]string{}
+						var total int64
+						c.Range(func(key int, value string) bool {
+							require.NotContains(t, stored, key, "Range visited a key twice")
+							stored[key] = value
+							total += int64(len(value))
+							return true
+						})
+						require.Equal(t, model, stored, "step %d", i)
+						if !weighted {
+							total = int64(len(stored))
+						}
+						weight := c.(strategies.WeightedCache[int, string]).Weight()
+						require.LessOrEqual(t, weight, int64(capacity))
+						require.Equal(t, total, weight)
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestWeigherRejectsOversized tests that an entry heavier than the whole capacity is rejected with a typed error
+func TestWeigherRejectsOversized(t *testing.T) {
+	for name, factory := range weightedFactories(10, byLength()) {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+			require.NoError(t, c.Set(1, "small"))
+
+			err := c.Set(2, strings.Repeat("x", 11))
+			var tooLarge *common.EntryTooLargeError
+			require.True(t, errors.As(err, &tooLarge))
+			assert.Equal(t, 2, tooLarge.Key)
+			assert.Equal(t, int64(11), tooLarge.Weight)
+			assert.Equal(t, int64(10), tooLarge.Capacity)
+
+			err = c.(cache.BatchCache[int, string]).SetMany(map[int]string{3: "abc", 4: strings.Repeat("x", 20)})
+			require.True(t, errors.As(err, &tooLarge))
+			assert.Equal(t, 4, tooLarge.Key)
+
+			_, err = c.Get(2)
+			assert.ErrorIs(t, err, common.ErrKeyNotFound)
+			_, err = c.Get(4)
+			assert.ErrorIs(t, err, common.ErrKeyNotFound)
+			assert.Equal(t, int64(8), c.(strategies.WeightedCache[int, string]).Weight())
+		})
+	}
+}
+
+// TestWeigherEvictsUntilFits tests that a heavy entry evicts as many light ones as it takes to make room for it
+func TestWeigherEvictsUntilFits(t *testing.T) {
+	for name, factory := range weightedFactories(100, byLength()) {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+			evictions := 0
+			c.(cache.ObservableCache[int, string]).OnEvent(func(event cache.Event[int, string]) {
+				if event.Type == cache.EventTypeEviction {
+					evictions++
+				}
+			})
+			for i := 0; i < 10; i++ {
+				require.NoError(t, c.Set(i, strings.Repeat("x", 10)))
+			}
+
+			require.NoError(t, c.Set(100, strings.Repeat("y", 55)))
+
+			value, err := c.Get(100)
+			require.NoError(t, err)
+			assert.Len(t, value, 55)
+			assert.Equal(t, int64(95), c.(strategies.WeightedCache[int, string]).Weight())
+			assert.Equal(t, 6, evictions)
+		})
+	}
+}
+
+// TestWeightWithoutWeigher tests that every entry weighs 1 by default
+func TestWeightWithoutWeigher(t *testing.T) {
+	for name, factory := range weightedFactories(8) {
+		t.Run(name, func(t *testing.T) {
+			c := factory()
+			for i := 0; i < 5; i++ {
+				require.NoError(t, c.Set(i, strings.Repeat("x", 100)))
+			}
+			assert.Equal(t, int64(5), c.(strategies.WeightedCache[int, string]).Weight())
+
+			for i := 5; i < 20; i++ {
+				require.NoError(t, c.Set(i, "x"))
+			}
+			assert.Equal(t, int64(8), c.(strategies.WeightedCache[int, string]).Weight())
+		})
+	}
+}
+
+// TestShardedWeight tests that a sharded cache adds up the weight of its shards
+func TestShardedWeight(t *testing.T) {
+	c := strategies.NewShardedCache[int, string](400, 4, nil, func(capacity int) strategies.CacheFactory[int, string] {
+		return strategies.NewLruCache[int, string](capacity, byLength())
+	})()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, c.Set(i, strings.Repeat("x", i)))
+	}
+	assert.Equal(t, int64(45), c.(strategies.WeightedCache[int, string]).Weight())
+}
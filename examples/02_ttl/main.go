@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"github.com/kimvlry/caching/cache/clock/clocktest"
 	"github.com/kimvlry/caching/cache/decorators"
 	"github.com/kimvlry/caching/cache/strategies"
 	"time"
@@ -14,12 +15,16 @@ type UserSession struct {
 }
 
 func main() {
-	baseTTL := strategies.NewTtlCache[string, UserSession](100, 10*time.Second)()
+	// A fake clock lets the example skip ahead instead of sleeping; real code leaves out WithClock
+	clk := clocktest.NewFakeClock(time.Now())
+	baseTTL := strategies.NewTtlCache[string, UserSession](100, 10*time.Second,
+		strategies.WithClock[string, UserSession](clk),
+	)()
 	cache := decorators.WithMetrics(baseTTL)
 
-	session1 := UserSession{UserID: "user123", Username: "alice", LoginTime: time.Now()}
-	session2 := UserSession{UserID: "user456", Username: "bob", LoginTime: time.Now()}
-	session3 := UserSession{UserID: "user789", Username: "charlie", LoginTime: time.Now()}
+	session1 := UserSession{UserID: "user123", Username: "alice", LoginTime: clk.Now()}
+	session2 := UserSession{UserID: "user456", Username: "bob", LoginTime: clk.Now()}
+	session3 := UserSession{UserID: "user789", Username: "charlie", LoginTime: clk.Now()}
 
 	_ = cache.Set("sess_long", session1)
 	fmt.Println("✓ Added long-lived session (10s NewTtlCache)")
@@ -42,8 +47,8 @@ func main() {
 	fmt.Printf("\nStats: Hits: %d, Misses: %d, Hit Rate: %.2f%%\n",
 		cache.GetHits(), cache.GetMisses(), cache.HitRate()*100)
 
-	fmt.Println("\n--- Waiting 4 seconds (short session expires) ---")
-	time.Sleep(4 * time.Second)
+	fmt.Println("\n--- 4 seconds later (short session expires) ---")
+	clk.Advance(4 * time.Second)
 
 	checkSession(cache, "sess_long")
 	checkSession(cache, "sess_short")
@@ -52,8 +57,8 @@ func main() {
 	fmt.Printf("\nStats: Hits: %d, Misses: %d, Evictions: %d, Hit Rate: %.2f%%\n",
 		cache.GetHits(), cache.GetMisses(), cache.GetEvictions(), cache.HitRate()*100)
 
-	fmt.Println("\n--- Waiting 7 more seconds (long session expires) ---")
-	time.Sleep(7 * time.Second)
+	fmt.Println("\n--- 7 more seconds later (long session expires) ---")
+	clk.Advance(7 * time.Second)
 
 	checkSession(cache, "sess_long")
 	checkSession(cache, "sess_short")